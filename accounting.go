@@ -0,0 +1,49 @@
+package smpp
+
+import (
+	"maps"
+	"sync"
+)
+
+// Accounting tallies the segments a client submits, per source and per
+// destination address, for billing. The zero value is ready to use and it
+// is safe for concurrent sends. Register it with WithAccounting.
+type Accounting struct {
+	mu       sync.Mutex
+	total    uint64
+	bySource map[string]uint64
+	byDest   map[string]uint64
+}
+
+// AccountingSnapshot is a point-in-time copy of an Accounting's tallies
+type AccountingSnapshot struct {
+	Total    uint64
+	BySource map[string]uint64
+	ByDest   map[string]uint64
+}
+
+// record adds segments accepted by the SMSC for source and dest
+func (a *Accounting) record(source, dest string, segments int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.bySource == nil {
+		a.bySource = make(map[string]uint64)
+		a.byDest = make(map[string]uint64)
+	}
+	a.total += uint64(segments)
+	a.bySource[source] += uint64(segments)
+	a.byDest[dest] += uint64(segments)
+}
+
+// Snapshot returns a copy of the current tallies
+func (a *Accounting) Snapshot() AccountingSnapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return AccountingSnapshot{
+		Total:    a.total,
+		BySource: maps.Clone(a.bySource),
+		ByDest:   maps.Clone(a.byDest),
+	}
+}
@@ -0,0 +1,53 @@
+package smpp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddressOverflow(t *testing.T) {
+	long := "998901234567890123456" // 21 digits, one over the limit
+	cases := []struct {
+		name string
+		msg  SMSMessage
+	}{
+		{"source_addr", SMSMessage{SourceAddr: long, DestAddr: "998901234567"}},
+		{"destination_addr", SMSMessage{SourceAddr: "Shop", DestAddr: long}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			submits := make(chan *DeliverSM, 1)
+			smsc := newTestSMSC(t, recordSubmits(submits))
+
+			// Rejected by default, before anything is sent
+			c := smsc.client()
+			connect(t, c)
+			msg := tc.msg
+			msg.Message = []byte("hi")
+			_, err := c.SendSMS(&msg)
+			if err == nil || !strings.Contains(err.Error(), tc.name) {
+				t.Fatalf("err = %v, want one naming %s", err, tc.name)
+			}
+			select {
+			case <-submits:
+				t.Fatal("over-length address sent")
+			default:
+			}
+
+			// Truncated to 20 characters on request
+			c = smsc.client(WithAddressOverflow(AddressOverflowTruncate))
+			connect(t, c)
+			if _, err := c.SendSMS(&msg); err != nil {
+				t.Fatal(err)
+			}
+			sm := <-submits
+			got := sm.Source.Addr
+			if tc.name == "destination_addr" {
+				got = sm.Dest.Addr
+			}
+			if got != long[:20] {
+				t.Fatalf("%s = %q, want %q", tc.name, got, long[:20])
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package smpp
+
+import "fmt"
+
+// maxESMEAddressLength is the maximum length of the 3.4 alert_notification
+// address fields, including the NULL terminator
+const maxESMEAddressLength = 65
+
+// Address is an SMPP address with its type of number and numbering plan
+type Address struct {
+	TON  byte
+	NPI  byte
+	Addr string
+}
+
+// MSAvailability is the ms_availability_status of an alert_notification
+type MSAvailability byte
+
+const (
+	MSAvailable   MSAvailability = 0 // available, the default when the TLV is absent
+	MSDenied      MSAvailability = 1 // denied: suspended, no SMS capability, ...
+	MSUnavailable MSAvailability = 2 // unavailable
+)
+
+func (a MSAvailability) String() string {
+	switch a {
+	case MSAvailable:
+		return "available"
+	case MSDenied:
+		return "denied"
+	case MSUnavailable:
+		return "unavailable"
+	default:
+		return fmt.Sprintf("MSAvailability(%d)", byte(a))
+	}
+}
+
+// AlertNotification tells the ESME that a mobile subscriber, for which
+// delivery was pending, has become reachable
+type AlertNotification struct {
+	SourceAddr   Address // the mobile subscriber
+	ESMEAddr     Address // the ESME address the alert is for
+	Availability MSAvailability
+}
+
+// parseAlertNotification decodes an alert_notification body
+func parseAlertNotification(body []byte) (*AlertNotification, error) {
+	r := &bodyReader{b: body}
+	var a AlertNotification
+	var err error
+	for _, addr := range []struct {
+		name string
+		dst  *Address
+	}{{"source_addr", &a.SourceAddr}, {"esme_addr", &a.ESMEAddr}} {
+		if addr.dst.TON, err = r.octet(addr.name + "_ton"); err != nil {
+			return nil, err
+		}
+		if addr.dst.NPI, err = r.octet(addr.name + "_npi"); err != nil {
+			return nil, err
+		}
+		if addr.dst.Addr, err = r.cString(addr.name, maxESMEAddressLength); err != nil {
+			return nil, err
+		}
+	}
+
+	tlvs, err := r.tlvs()
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := tlvs[TLV_MS_AVAILABILITY_STATUS]; ok {
+		if len(v) != 1 {
+			return nil, fmt.Errorf("ms_availability_status has length %d", len(v))
+		}
+		a.Availability = MSAvailability(v[0])
+	}
+	return &a, nil
+}
+
+// OnAlertNotification sets a function called with every alert_notification
+// the SMSC sends. It runs on its own goroutine. Without a handler alerts
+// are ignored; the protocol defines no response to them.
+func (c *Client) OnAlertNotification(fn func(*AlertNotification)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onAlert = fn
+}
+
+// handleAlertNotification dispatches an alert_notification. Must be called
+// with c.mu held.
+func (c *Client) handleAlertNotification(req *pdu) {
+	if c.onAlert == nil {
+		return
+	}
+	alert, err := parseAlertNotification(req.body)
+	if err != nil {
+		c.logger.Warn("smpp: malformed alert_notification", "error", err)
+		return
+	}
+	go c.onAlert(alert)
+}
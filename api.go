@@ -1,190 +1,788 @@
 package smpp
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type SMSMessage struct {
-	SourceAddr            string
-	DestAddr              string
-	Message               []byte
-	DataCoding            byte
-	IsUnicode             bool
-	IsBinary              bool
+	ServiceType string
+	SourceAddr  string
+	DestAddr    string
+	Message     []byte
+
+	// SourceAddrTON and SourceAddrNPI describe SourceAddr; the zero values
+	// (unknown/unknown) suit most SMSCs, or are filled in from SourceAddr
+	// with WithAutoSourceAddressing. Alphanumeric sender IDs use TON 5.
+	SourceAddrTON byte
+	SourceAddrNPI byte
+
+	// DataCoding selects the content encoding when IsUnicode and IsBinary
+	// are unset. The standard values (DataCodingGSM7, DataCodingLatin1,
+	// DataCodingUCS2, DataCodingBinary) are translated through the client's
+	// DataCodingMap; any other value is sent as is.
+	DataCoding byte
+	IsUnicode  bool
+	IsBinary   bool
+
+	// Priority is the priority_flag; the allowed range depends on the
+	// client's network type (0-3 for GSM, 0-4 for ANSI-136 and IS-95)
+	Priority byte
+
+	// ScheduleDeliveryTime defers delivery until the given time; zero
+	// delivers immediately.
+	ScheduleDeliveryTime time.Time
+
+	// ValidityPeriod is how long the SMSC keeps retrying delivery, sent as
+	// a relative time; zero uses the SMSC default.
+	ValidityPeriod time.Duration
+
+	// RegisteredDelivery selects the delivery receipt, SME acknowledgements
+	// and intermediate notifications requested for the message.
+	RegisteredDelivery RegisteredDelivery
+
+	// RequestDeliveryReport is a shorthand for a ReceiptSuccessOrFailure
+	// receipt, used only when RegisteredDelivery is empty.
 	RequestDeliveryReport bool
+
+	// UsePayload sends Message in the message_payload TLV with sm_length=0
+	// regardless of its length, as some SMSCs require for binary/WAP push.
+	UsePayload bool
+
+	// AllowEmpty permits sending a zero-length Message. Many SMSCs reject
+	// empty submits, while others accept them as a presence check or
+	// keepalive towards the handset, so SendSMS refuses them unless this
+	// is set.
+	AllowEmpty bool
+
+	// SourcePort and DestPort address an application on the handset, as
+	// for WAP push (destination port 2948); nil leaves the TLV out.
+	SourcePort *uint16
+	DestPort   *uint16
+
+	// SendDeadline drops the message with ErrDeadlineExpired if the client
+	// has not sent it by then, for example because it waited for a bind or
+	// the rate limiter. Unlike ValidityPeriod it only governs the local
+	// wait; zero means no deadline. A concatenated message is checked
+	// before its first part, and once that is sent the rest follow.
+	SendDeadline time.Time
+
+	// MoreMessagesToSend tells the SMSC another message for the same
+	// destination follows, so it can keep the radio channel open.
+	// SendLongSMS sets it on every part but the last.
+	MoreMessagesToSend bool
+
+	// ITSReplyType and ITSSessionInfo drive menu-based interactive
+	// teleservice exchanges; nil leaves the TLV out.
+	ITSReplyType   *ITSReplyType
+	ITSSessionInfo *ITSSessionInfo
+
+	// EsmClass sets the messaging mode, message type, reply path and UDHI
+	// bits of esm_class. They are combined with the UDHI bit SendSMS sets
+	// itself for concatenated parts.
+	EsmClass EsmClass
+
+	// udhi marks Message as starting with a user data header
+	udhi bool
+
+	// continuation marks a later part of a concatenated message, already
+	// admitted by the destination limit with the first
+	continuation bool
+}
+
+// deliveryTimes returns msg's schedule_delivery_time and validity_period,
+// empty for the SMSC defaults
+func (msg *SMSMessage) deliveryTimes() (schedule, validity string) {
+	if !msg.ScheduleDeliveryTime.IsZero() {
+		schedule = FormatSMPPTime(msg.ScheduleDeliveryTime)
+	}
+	if msg.ValidityPeriod > 0 {
+		validity = FormatSMPPDuration(msg.ValidityPeriod)
+	}
+	return schedule, validity
+}
+
+// registeredDelivery returns msg's registered_delivery, honouring the
+// RequestDeliveryReport shorthand
+func (msg *SMSMessage) registeredDelivery() byte {
+	regDelivery := msg.RegisteredDelivery.Encode()
+	if regDelivery == 0 && msg.RequestDeliveryReport {
+		regDelivery = byte(ReceiptSuccessOrFailure)
+	}
+	return regDelivery
+}
+
+// ErrDeadlineExpired is returned for a message whose SendDeadline passed
+// before it could be sent
+var ErrDeadlineExpired = errors.New("smpp: send deadline passed before the message was sent")
+
+// expired reports whether msg's SendDeadline has passed at now
+func (msg *SMSMessage) expired(now time.Time) bool {
+	return !msg.SendDeadline.IsZero() && !now.Before(msg.SendDeadline)
+}
+
+// Validate reports settings of msg that contradict each other or cannot be
+// sent, independently of any client configuration. SendSMS calls it before
+// encoding.
+func (msg *SMSMessage) Validate() error {
+	if len(msg.Message) == 0 && !msg.AllowEmpty {
+		return errors.New("message is empty; set AllowEmpty to send a zero-length message")
+	}
+	if msg.IsUnicode && msg.IsBinary {
+		return errors.New("IsUnicode and IsBinary are both set")
+	}
+	if msg.IsUnicode && msg.DataCoding != DataCodingGSM7 && msg.DataCoding != DataCodingUCS2 {
+		return fmt.Errorf("IsUnicode conflicts with DataCoding 0x%02X", msg.DataCoding)
+	}
+	if msg.IsBinary && msg.DataCoding != DataCodingGSM7 && msg.DataCoding != DataCodingBinary {
+		return fmt.Errorf("IsBinary conflicts with DataCoding 0x%02X", msg.DataCoding)
+	}
+	return nil
 }
 
 type Client struct {
-	conn        *connection
-	systemID    string
-	password    string
-	bound       bool
-	sequenceNum uint32
+	mu       sync.Mutex // serializes writes, and whole exchanges without a read loop
+	conn     *connection
+	systemID string
+	password string
+	state    *stateMachine
+	sequence SequenceGenerator
+
+	// bind parameters
+	bindMode     BindMode
+	credEnc      CredentialEncoding
+	addrTON      byte
+	addrNPI      byte
+	addressRange string
+
+	useTLS             bool
+	tlsConfig          *tls.Config
+	insecureSkipVerify bool
+
+	logger *slog.Logger
+	clock  Clock
+
+	// submit_sm validation and encoding
+	profile               string
+	region                string
+	network               NetworkType
+	dataCodings           DataCodingMap
+	encoding              EncodingStrategy
+	maxShortMessageOctets int
+	maxPayloadOctets      int
+	wordBoundaries        bool
+	maxSegments           int
+	autoPayload           bool
+	autoPayloadThreshold  int
+	addressOverflow       AddressOverflow
+	classifySource        SourceAddrClassifier // nil unless WithAutoSourceAddressing
+	quirks                Quirks
+	otpDefaults           OTPDefaults
+	drain                 drain
+	concatRef             atomic.Uint32
+
+	// unbound submit queue
+	queueSlots   chan struct{} // nil unless WithUnboundQueue
+	queueTimeout time.Duration
+
+	successStatus func(status uint32) bool
+
+	limiter           *rateLimiter
+	rampFraction      float64 // share of the rate limit to restart at after a bind
+	backpressure      backpressure
+	queueFullCooldown time.Duration
+	destLimiter       *destLimiter
+	accounting        *Accounting
+	recentIDs         *idTracker // nil unless WithDuplicateDetection
+	dedup             *dedup     // nil unless WithDedupWindow
+	hexIDs            *idMap     // nil unless QuirkHexMessageIDs
+	beforeSend        func(commandID uint32, body []byte) []byte
+
+	dispatch         atomic.Pointer[dispatcher] // set while a read loop runs
+	window           chan struct{}              // a slot per pipelined submit awaiting its response
+	windowSize       int                        // capacity of window, set by WithWindow
+	inbound          chan inboundPDU            // deliver_sm waiting for the handler
+	onDeliver        func(*DeliverSM) map[uint16][]byte
+	onSMEAck         func(*DeliverSM)
+	receipts         receiptWaiters // SubmitAndWaitReceipt calls
+	deliverOnce      sync.Once      // starts the workers running onDeliver
+	deliverWorkers   int
+	inboundBuffer    int
+	commands         map[uint32]func(*DecodedPDU) *Response
+	onConnectionLost func(err error)
+	onAlert          func(*AlertNotification)
+	reconnects       *ReconnectLimiter
+
+	// enquire_link keepalive
+	enquireInterval time.Duration // zero unless WithEnquireLink
+	enquireTimeout  time.Duration
+	keepaliveMu     sync.Mutex    // guards keepaliveStop without waiting for c.mu
+	keepaliveStop   chan struct{} // closed to end the session's keepalive loop
+
+	idleTimeout  time.Duration // zero unless WithIdleTimeout
+	receiptGrace time.Duration // zero unless WithReceiptGrace
+
+	counters counters
 }
 
-func NewClient(host string, port int, systemID, password string) *Client {
-	return &Client{
-		conn:        newConnection(host, port, 10*time.Second, 30*time.Second),
-		systemID:    systemID,
-		password:    password,
-		bound:       false,
-		sequenceNum: 1,
+func NewClient(host string, port int, systemID, password string, opts ...Option) *Client {
+	c := &Client{
+		conn:     newConnection(host, port, 10*time.Second, 30*time.Second),
+		systemID: systemID,
+		password: password,
+		state:    newStateMachine(),
+		sequence: &counterSequence{},
+		logger:   slog.New(slog.DiscardHandler),
+		clock:    realClock{},
+
+		dataCodings:           DefaultDataCodingMap,
+		maxShortMessageOctets: defaultMaxShortMessageOctets,
+		maxPayloadOctets:      maxTLVLength,
+		maxSegments:           defaultMaxSegments,
+		inboundBuffer:         defaultInboundBuffer,
+		deliverWorkers:        1,
+		windowSize:            defaultWindow,
+		rampFraction:          1,
+		queueFullCooldown:     defaultQueueFullCooldown,
+		successStatus:         func(status uint32) bool { return status == ESME_ROK },
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.window = make(chan struct{}, c.windowSize)
+	return c
 }
 
 func (c *Client) Connect(useTLS bool) error {
-	var err error
+	return c.ConnectContext(context.Background(), useTLS)
+}
+
+// ConnectContext is Connect with ctx bounding the whole dial, TLS and bind
+// sequence on top of the per-step connect timeout. Failures are reported as
+// a *ConnectError naming the stage; if ctx ends first its Err is ctx.Err().
+func (c *Client) ConnectContext(ctx context.Context, useTLS bool) error {
+	if err := c.state.transition(StateDialing); err != nil {
+		return err
+	}
 
-	if useTLS {
-		err = c.conn.connectTLS(nil)
+	// Under c.mu, as the read loop of a lost session may still be checking
+	// whether its connection is current
+	var err error
+	c.mu.Lock()
+	if useTLS || c.useTLS {
+		err = c.conn.connectTLS(ctx, c.effectiveTLSConfig())
 	} else {
-		err = c.conn.connect()
+		err = c.conn.connect(ctx)
 	}
+	c.mu.Unlock()
 
 	if err != nil {
-		return err
+		c.state.transition(StateDisconnected)
+		return withContextErr(ctx, err)
 	}
 
+	c.state.transition(StateOpen)
+	c.state.transition(StateBinding)
+	stop := c.conn.closeOnDone(ctx)
+	start := c.clock.Now()
 	err = c.bind()
+	c.conn.trace.Bind = c.clock.Now().Sub(start)
+	stop()
 	if err != nil {
 		c.conn.close()
-		return err
+		c.state.transition(StateDisconnected)
+		return withContextErr(ctx, &ConnectError{"bind", err})
 	}
 
 	return nil
 }
 
+// withContextErr replaces the cause of a connect error with ctx's error
+// when ctx ending was the reason the stage failed
+func withContextErr(ctx context.Context, err error) error {
+	var ce *ConnectError
+	if ctx.Err() != nil && errors.As(err, &ce) {
+		return &ConnectError{ce.Stage, ctx.Err()}
+	}
+	return err
+}
+
+// effectiveTLSConfig applies WithInsecureSkipVerify to the configured TLS
+// settings, warning whenever certificate verification ends up disabled
+func (c *Client) effectiveTLSConfig() *tls.Config {
+	config := c.tlsConfig
+	if c.insecureSkipVerify {
+		if config == nil {
+			config = &tls.Config{}
+		} else {
+			config = config.Clone()
+		}
+		config.InsecureSkipVerify = true
+	}
+	if config != nil && config.InsecureSkipVerify {
+		c.logger.Warn("smpp: TLS certificate verification is disabled", "host", c.conn.host)
+	}
+	return config
+}
+
+// ConnectTrace returns the timing breakdown of the most recent Connect, to
+// tell slow networks from slow SMSCs
+func (c *Client) ConnectTrace() ConnectTrace {
+	return c.conn.trace
+}
+
+// ConnectionState returns the negotiated TLS version, cipher suite and
+// peer certificates of the most recent connection, and false if it was
+// not made with TLS
+func (c *Client) ConnectionState() (*tls.ConnectionState, bool) {
+	state := c.conn.tlsState
+	return state, state != nil
+}
+
 func (c *Client) bind() error {
-	pdu := newPDU(BIND_TRANSMITTER, c.nextSequence())
-	pdu.writeString(c.systemID)
-	pdu.writeString(c.password)
-	pdu.writeString("") // system_type
-	pdu.writeByte(0x34) // interface version (3.4)
-	pdu.writeByte(0)    // addr_ton
-	pdu.writeByte(0)    // addr_npi
-	pdu.writeString("") // address_range
+	systemID, err := c.credEnc.encode(c.systemID)
+	if err != nil {
+		return fmt.Errorf("system_id: %w", err)
+	}
+	password, err := c.credEnc.encode(c.password)
+	if err != nil {
+		return fmt.Errorf("password: %w", err)
+	}
 
-	resp, err := c.sendPDU(pdu)
+	pdu := newPDU(c.bindMode.commandID(), c.nextSequence())
+	if err := pdu.writeCString("system_id", systemID, maxSystemIDLength); err != nil {
+		return err
+	}
+	if err := pdu.writeCString("password", password, maxPasswordLength); err != nil {
+		return errors.New("password exceeds 8 characters") // without echoing it
+	}
+	pdu.writeString("")      // system_type
+	pdu.writeByte(0x34)      // interface version (3.4)
+	pdu.writeByte(c.addrTON) // addr_ton
+	pdu.writeByte(c.addrNPI) // addr_npi
+	if err := pdu.writeCString("address_range", c.addressRange, maxAddressRangeLength); err != nil {
+		return err
+	}
+
+	// Never bound, so a failure here is not a lost connection
+	resp, err := c.roundTrip(pdu)
 	if err != nil {
 		return err
 	}
 
-	if resp.commandStatus != 0 {
-		return errors.New("bind failed")
+	if resp.commandStatus != ESME_ROK {
+		return &StatusError{resp.commandStatus}
 	}
 
-	c.bound = true
+	if c.limiter != nil {
+		c.limiter.reset(c.rampFraction, c.clock.Now())
+	}
+	if c.bindMode.readsContinuously() {
+		c.startReadLoop()
+	}
+	// Becoming bound releases submits waiting in the unbound queue
+	if err := c.state.transition(StateBound); err != nil {
+		return err
+	}
+	c.counters.binds.Add(1)
+	c.startKeepalive()
 	return nil
 }
 
+// awaitBind returns at once when bound. Otherwise, if WithUnboundQueue is
+// enabled, it holds the submit until the next bind succeeds.
+func (c *Client) awaitBind() error {
+	if c.State() == StateBound {
+		return nil
+	}
+	if c.queueSlots == nil {
+		return ErrNotBound
+	}
+	select {
+	case c.queueSlots <- struct{}{}:
+		defer func() { <-c.queueSlots }()
+	default:
+		return fmt.Errorf("%w and unbound queue is full", ErrNotBound)
+	}
+
+	signal := c.state.boundSignal()
+	if signal == nil {
+		return nil
+	}
+
+	select {
+	case <-signal:
+		return nil
+	case <-c.clock.After(c.queueTimeout):
+		return fmt.Errorf("%w within %s", ErrNotBound, c.queueTimeout)
+	}
+}
+
 func (c *Client) SendSMS(msg *SMSMessage) (string, error) {
-	if !c.bound {
-		return "", errors.New("not bound to SMPP server")
+	messageID, _, err := c.submit(SUBMIT_SM, msg)
+	return messageID, err
+}
+
+// SendDataSM sends msg as a data_sm instead of a submit_sm, for carriers
+// that require it, and returns the message ID. data_sm carries the content
+// only in message_payload, whatever UsePayload says, and has no priority,
+// schedule or validity fields, so Priority, ScheduleDeliveryTime and
+// ValidityPeriod are not sent. Otherwise msg is handled as by SendSMS.
+func (c *Client) SendDataSM(msg *SMSMessage) (string, error) {
+	messageID, _, err := c.submit(DATA_SM, msg)
+	return messageID, err
+}
+
+// SubmitResponse is a submit_sm_resp as returned by SubmitSMRaw
+type SubmitResponse struct {
+	MessageID string
+	Raw       []byte // the whole PDU, header included, as received
+
+	// NumberOfMessages is how many messages the submission is billed as.
+	// It comes from the number_of_messages TLV when the SMSC sends one,
+	// as reported by NumberOfMessagesFromSMSC, and is otherwise the
+	// client's own count of the segments a payload message needs.
+	NumberOfMessages         int
+	NumberOfMessagesFromSMSC bool
+}
+
+// SubmitSMRaw sends msg like SendSMS and also returns the exact
+// submit_sm_resp bytes for archiving. When the SMSC rejects the message
+// both the response and the status error are returned. A duplicate
+// suppressed by WithDedupWindow has only its earlier MessageID set.
+func (c *Client) SubmitSMRaw(msg *SMSMessage) (*SubmitResponse, error) {
+	messageID, resp, err := c.submit(SUBMIT_SM, msg)
+	if resp == nil {
+		if err == nil {
+			return &SubmitResponse{MessageID: messageID}, nil
+		}
+		return nil, err
+	}
+	result := &SubmitResponse{MessageID: messageID, Raw: resp.encode()}
+	_, tlvs := c.parseSubmitResp(resp.body)
+	if v := tlvs[TLV_NUMBER_OF_MESSAGES]; len(v) == 1 {
+		result.NumberOfMessages = int(v[0])
+		result.NumberOfMessagesFromSMSC = true
+	} else {
+		result.NumberOfMessages = c.countMessages(msg)
 	}
+	return result, err
+}
 
-	// Set data coding based on content type
-	dataCoding := byte(0) // Default GSM
-	if msg.IsUnicode {
-		dataCoding = 0x08 // UCS2
-	} else if msg.IsBinary {
-		dataCoding = 0x04 // Binary
+// countMessages estimates how many messages the SMSC generates for msg
+// sent as a single submit_sm: one, unless the message_payload content
+// exceeds a single short message and has to be split
+func (c *Client) countMessages(msg *SMSMessage) int {
+	if !msg.UsePayload || len(msg.Message) <= c.singlePartLength(msg) {
+		return 1
+	}
+	return max(len(c.segmentMessage(msg, 0)), 1)
+}
+
+// submitRespFields splits a submit_sm_resp body into the message ID and
+// its optional parameters. SMSCs that omit the NULL terminator send only
+// the ID; malformed optional parameters are ignored.
+func submitRespFields(body []byte) (string, map[uint16][]byte) {
+	end := bytes.IndexByte(body, 0)
+	if end < 0 {
+		return string(body), nil
+	}
+	r := &bodyReader{b: body, off: end + 1}
+	tlvs, err := r.tlvs()
+	if err != nil {
+		return string(body[:end]), nil
 	}
+	return string(body[:end]), tlvs
+}
+
+// submit sends a single submit_sm or data_sm, per commandID, and returns
+// the message ID and the response PDU, if one was received
+func (c *Client) submit(commandID uint32, msg *SMSMessage) (string, *pdu, error) {
+	var key dedupKey
+	if c.dedup != nil {
+		key = newDedupKey(msg)
+		if messageID, ok := c.dedup.lookup(key, c.clock.Now()); ok {
+			c.logger.Warn("smpp: suppressed duplicate submit", "message_id", messageID, "dest", msg.DestAddr)
+			return messageID, nil, nil
+		}
+	}
+
+	if err := c.awaitBind(); err != nil {
+		return "", nil, err
+	}
+	if !c.drain.enter() {
+		return "", nil, ErrShuttingDown
+	}
+	defer c.drain.leave()
 
-	pdu := newPDU(SUBMIT_SM, c.nextSequence())
+	messageID, resp, err := c.submitOne(commandID, msg)
+	if err == nil && c.dedup != nil {
+		c.dedup.remember(key, messageID, c.clock.Now())
+	}
+	return messageID, resp, err
+}
+
+// submitOne sends a submit_sm or data_sm, per commandID, within an
+// operation registered with the drain
+func (c *Client) submitOne(commandID uint32, msg *SMSMessage) (string, *pdu, error) {
+	pdu, destAddr, err := c.newMessagePDU(commandID, msg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if c.destLimiter != nil && !msg.continuation && !c.destLimiter.allow(destAddr, c.clock.Now()) {
+		return "", nil, ErrDestinationThrottled
+	}
+
+	c.backpressure.wait(c.clock)
+	if c.limiter != nil {
+		c.limiter.wait(c.clock)
+	}
+	if msg.expired(c.clock.Now()) {
+		return "", nil, ErrDeadlineExpired
+	}
+	if c.drain.aborted() {
+		c.drain.record(false)
+		return "", nil, ErrShuttingDown
+	}
+
+	// Send the PDU
+	resp, err := c.sendPDU(pdu)
+	c.drain.record(err == nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	messageID, err := c.submitResult(msg, destAddr, resp)
+	return messageID, resp, err
+}
+
+// newMessagePDU validates msg and encodes it as a submit_sm or data_sm
+// PDU, per commandID. It also returns the destination address actually
+// used, after normalization.
+func (c *Client) newMessagePDU(commandID uint32, msg *SMSMessage) (*pdu, string, error) {
+	if c.State() != StateBound {
+		return nil, "", ErrNotBound
+	}
+	if !c.bindMode.canSubmit() {
+		return nil, "", errReceiverSubmit
+	}
+
+	if err := msg.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if c.profile != "" {
+		profile, err := lookupProfile(c.profile)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := profile.Validate(msg); err != nil {
+			return nil, "", fmt.Errorf("profile %s: %w", c.profile, err)
+		}
+	}
+
+	if maxPriority := c.network.maxPriority(); msg.Priority > maxPriority {
+		return nil, "", fmt.Errorf("priority_flag %d out of range 0-%d for %s", msg.Priority, maxPriority, c.network)
+	}
+
+	destAddr := msg.DestAddr
+	if c.region != "" {
+		var err error
+		destAddr, err = NormalizeE164(destAddr, c.region)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Set data coding based on content type
+	dataCoding := c.dataCodings.dataCoding(msg)
+
+	pdu := newPDU(commandID, c.nextSequence())
 
 	// Add mandatory parameters
-	pdu.writeString("") // service_type
-	pdu.writeByte(0)    // source_addr_ton
-	pdu.writeByte(0)    // source_addr_npi
-	pdu.writeString(msg.SourceAddr)
+	serviceType := msg.ServiceType
+	if c.quirks.has(QuirkEmptyServiceType) {
+		serviceType = ""
+	}
+	if err := pdu.writeCString("service_type", serviceType, maxServiceTypeLength); err != nil {
+		return nil, "", err
+	}
+	sourceAddr, err := c.fitAddress("source_addr", msg.SourceAddr)
+	if err != nil {
+		return nil, "", err
+	}
+	destAddr, err = c.fitAddress("destination_addr", destAddr)
+	if err != nil {
+		return nil, "", err
+	}
+	sourceTON, sourceNPI := c.sourceAddressing(msg)
+	pdu.writeByte(sourceTON) // source_addr_ton
+	pdu.writeByte(sourceNPI) // source_addr_npi
+	pdu.writeString(sourceAddr)
 	pdu.writeByte(1) // dest_addr_ton
 	pdu.writeByte(1) // dest_addr_npi
-	pdu.writeString(msg.DestAddr)
+	pdu.writeString(destAddr)
 
-	esmClass := byte(0)
-	if msg.IsBinary {
-		esmClass |= 0x04 // Set binary flag
+	// Binary content is marked by data_coding alone; esm_class has no
+	// binary bit
+	esmClass := msg.EsmClass.Encode()
+	if msg.udhi {
+		esmClass |= esmClassUDHI
 	}
 	pdu.writeByte(esmClass) // esm_class
-	pdu.writeByte(0)        // protocol_id
-	pdu.writeByte(0)        // priority_flag
-	pdu.writeString("")     // schedule_delivery_time
-	pdu.writeString("")     // validity_period
+	if commandID == DATA_SM {
+		pdu.writeByte(msg.registeredDelivery()) // registered_delivery
+		pdu.writeByte(dataCoding)               // data_coding
+	} else {
+		pdu.writeByte(0)            // protocol_id
+		pdu.writeByte(msg.Priority) // priority_flag
+		schedule, validity := msg.deliveryTimes()
+		pdu.writeString(schedule) // schedule_delivery_time
+		pdu.writeString(validity) // validity_period
 
-	// Set registered delivery if delivery report requested
-	regDelivery := byte(0)
-	if msg.RequestDeliveryReport {
-		regDelivery = 1
+		pdu.writeByte(msg.registeredDelivery()) // registered_delivery
+		pdu.writeByte(0)                        // replace_if_present_flag
+		pdu.writeByte(dataCoding)               // data_coding
+		pdu.writeByte(0)                        // sm_default_msg_id
+	}
+
+	// Place the content in exactly one of short_message or message_payload;
+	// data_sm has only message_payload
+	var shortMessage, payload []byte
+	if msg.UsePayload || commandID == DATA_SM {
+		payload = msg.Message
+	} else {
+		shortMessage = msg.Message
 	}
-	pdu.writeByte(regDelivery) // registered_delivery
-	pdu.writeByte(0)           // replace_if_present_flag
-	pdu.writeByte(dataCoding)  // data_coding
-	pdu.writeByte(0)           // sm_default_msg_id
 
 	// Handle message length
-	if len(msg.Message) > 254 {
+	if len(shortMessage) > c.maxShortMessageOctets {
 		// Message too long, return an error
-		return "", fmt.Errorf("message too long (%d bytes), max is 254 bytes", len(msg.Message))
-	} else {
-		pdu.writeByte(byte(len(msg.Message))) // sm_length
-		pdu.write(msg.Message)                // short_message
+		return nil, "", fmt.Errorf("message too long (%d bytes), max is %d bytes", len(shortMessage), c.maxShortMessageOctets)
+	}
+	if len(payload) > c.maxPayloadOctets {
+		return nil, "", fmt.Errorf("message payload too long (%d bytes), max is %d bytes", len(payload), c.maxPayloadOctets)
+	}
+	if commandID != DATA_SM {
+		pdu.writeByte(byte(len(shortMessage))) // sm_length
+		pdu.write(shortMessage)                // short_message
 	}
 
-	// Send the PDU
-	resp, err := c.sendPDU(pdu)
-	if err != nil {
-		return "", err
+	if payload != nil || commandID == DATA_SM {
+		pdu.writeTLV(TLV_MESSAGE_PAYLOAD, payload)
+	}
+	if msg.SourcePort != nil {
+		pdu.writeTLV(TLV_SOURCE_PORT, binary.BigEndian.AppendUint16(nil, *msg.SourcePort))
+	}
+	if msg.DestPort != nil {
+		pdu.writeTLV(TLV_DESTINATION_PORT, binary.BigEndian.AppendUint16(nil, *msg.DestPort))
+	}
+	if msg.MoreMessagesToSend {
+		pdu.writeTLV(TLV_MORE_MESSAGES, []byte{1})
+	}
+	if msg.ITSReplyType != nil {
+		pdu.writeTLV(TLV_ITS_REPLY_TYPE, []byte{byte(*msg.ITSReplyType)})
+	}
+	if msg.ITSSessionInfo != nil {
+		if msg.ITSSessionInfo.SequenceNumber > 0x7F {
+			return nil, "", fmt.Errorf("its_session_info sequence number %d exceeds 127", msg.ITSSessionInfo.SequenceNumber)
+		}
+		pdu.writeTLV(TLV_ITS_SESSION_INFO, msg.ITSSessionInfo.encode())
+	}
+
+	return pdu, destAddr, nil
+}
+
+// fitAddress applies the address overflow policy to an address field
+func (c *Client) fitAddress(field, addr string) (string, error) {
+	if len(addr) < maxAddressLength {
+		return addr, nil
+	}
+	if c.addressOverflow == AddressOverflowTruncate {
+		truncated := addr[:maxAddressLength-1]
+		c.logger.Warn("smpp: truncating over-length address", "field", field, "addr", addr, "sent", truncated)
+		return truncated, nil
 	}
+	return "", fmt.Errorf("%s %q exceeds %d characters", field, addr, maxAddressLength-1)
+}
 
-	if resp.commandStatus != 0 {
-		// Map some common SMPP error codes
-		errorMessage := "unknown error"
-		switch resp.commandStatus {
-		case 1:
-			errorMessage = "message ID invalid"
-		case 2:
-			errorMessage = "bind failed"
-		case 3:
-			errorMessage = "invalid priority flag"
-		case 4:
-			errorMessage = "invalid registered delivery flag"
-		case 5:
-			errorMessage = "system error"
-		case 6:
-			errorMessage = "invalid source address"
-		case 7:
-			errorMessage = "invalid destination address"
-		case 8:
-			errorMessage = "invalid message ID"
-		case 10:
-			errorMessage = "invalid message"
-		case 88:
-			errorMessage = "throttled - rate limit exceeded"
+// submitResult interprets a submit_sm_resp, feeding the rate limiter and
+// accounting, and returns the message ID
+func (c *Client) submitResult(msg *SMSMessage, destAddr string, resp *pdu) (string, error) {
+	success := c.successStatus(resp.commandStatus)
+	c.counters.submitted(resp.commandStatus, success)
+	if c.limiter != nil {
+		switch {
+		case success:
+			c.limiter.accepted()
+		case resp.commandStatus == ESME_RTHROTTLED:
+			c.limiter.throttled()
 		}
-		return "", fmt.Errorf("submit_sm failed with status: %d (%s)", resp.commandStatus, errorMessage)
 	}
 
-	// Extract message ID from response
-	messageID := ""
-	if len(resp.body) > 0 {
-		// Remove null terminator if present
-		if resp.body[len(resp.body)-1] == 0 {
-			messageID = string(resp.body[:len(resp.body)-1])
-		} else {
-			messageID = string(resp.body)
+	if resp.commandStatus == ESME_RMSGQFUL && !success {
+		c.queueFull()
+	}
+
+	if !success {
+		op := "submit_sm"
+		if resp.commandID == DATA_SM_RESP {
+			op = "data_sm"
 		}
+		return "", fmt.Errorf("%s failed: %w", op, &StatusError{resp.commandStatus})
+	}
+
+	// Extract message ID from response
+	messageID, _ := c.parseSubmitResp(resp.body)
+
+	if messageID != "" && c.recentIDs != nil && c.recentIDs.seen(messageID) {
+		c.counters.duplicateMessageIDs.Add(1)
+		c.logger.Warn("smpp: SMSC returned a duplicate message ID", "message_id", messageID, "dest", destAddr)
+	}
+
+	if c.accounting != nil {
+		c.accounting.record(msg.SourceAddr, destAddr, 1)
 	}
 
 	return messageID, nil
 }
 
+// SendLongSMS sends a message of any length and returns the ID of its first
+// part. The transport is chosen deterministically:
+//
+//   - UsePayload and content within the payload cap: one submit_sm carrying
+//     the content in message_payload
+//   - WithAutoPayload, an SMSC supporting message_payload and content above
+//     the threshold but within the payload cap: likewise
+//   - content fitting a single short_message: one plain submit_sm
+//   - otherwise: concatenated submit_sm parts, including UsePayload content
+//     above the payload cap, which the SMSC would reject
 func (c *Client) SendLongSMS(msg *SMSMessage) (string, error) {
-	// Define maximum length based on encoding
-	maxLength := 153 // For segmented GSM messages, we use 153 chars instead of 160
-	if msg.IsUnicode {
-		maxLength = 67 // For segmented Unicode messages, we use 67 chars instead of 70
+	if msg.UsePayload && len(msg.Message) <= c.maxPayloadOctets {
+		return c.SendSMS(msg)
+	}
+
+	maxLength := c.singlePartLength(msg)
+
+	if c.autoPayload && len(msg.Message) <= c.maxPayloadOctets {
+		threshold := c.autoPayloadThreshold
+		if threshold == 0 {
+			threshold = maxLength
+		}
+		if len(msg.Message) > threshold {
+			payloadMsg := *msg
+			payloadMsg.UsePayload = true
+			return c.SendSMS(&payloadMsg)
+		}
 	}
 
 	// If message is short enough, just send it normally
@@ -192,95 +790,476 @@ func (c *Client) SendLongSMS(msg *SMSMessage) (string, error) {
 		return c.SendSMS(msg)
 	}
 
-	// For longer messages, we need proper segmentation
-	messageLen := len(msg.Message)
-	partCount := (messageLen + maxLength - 1) / maxLength // Ceiling division
+	// For longer messages, split into UDH-prefixed concatenated parts
+	segments := c.segmentMessage(msg, c.nextConcatRef())
+	if segments == nil {
+		return "", fmt.Errorf("message too long (%d bytes), needs more than %d parts", len(msg.Message), maxSegments)
+	}
+	if len(segments) > c.maxSegments {
+		return "", fmt.Errorf("message needs %d parts, more than the limit of %d", len(segments), c.maxSegments)
+	}
 
-	// We'll only return the ID of the first message part
-	var firstMessageID string
+	// Only the ID of the first part is returned
+	messageIDs, err := c.sendParts(msg, segments)
+	if err != nil {
+		return "", err
+	}
+	return messageIDs[0], nil
+}
 
-	// Split message into parts and send each part
-	for i := 0; i < partCount; i++ {
-		// Calculate the start and end indices for this part
-		start := i * maxLength
-		end := start + maxLength
-		if end > messageLen {
-			end = messageLen
+// SendSegments sends content the caller has already split as one
+// concatenated message: each segment gets a concatenation UDH with a
+// shared reference number and is sent in order with base's other fields.
+// It returns the message ID of every part, or of the parts sent before an
+// error. Every segment must be non-empty and leave room for the 6-octet
+// UDH within the short_message limit; they are checked before the first
+// is sent, so a bad one cannot leave a message the handset cannot
+// reassemble.
+func (c *Client) SendSegments(base SMSMessage, segments [][]byte) ([]string, error) {
+	if len(segments) == 0 {
+		return nil, errors.New("no segments")
+	}
+	if len(segments) > c.maxSegments {
+		return nil, fmt.Errorf("%d segments, more than the limit of %d", len(segments), c.maxSegments)
+	}
+	room := c.maxShortMessageOctets - udhConcatLength
+	for i, seg := range segments {
+		if len(seg) == 0 {
+			return nil, fmt.Errorf("segment %d is empty", i+1)
 		}
+		if len(seg) > room {
+			return nil, fmt.Errorf("segment %d has %d bytes, max is %d bytes with the UDH", i+1, len(seg), room)
+		}
+	}
+	parts := addConcatUDH(segments, c.nextConcatRef())
+	if parts == nil {
+		return nil, fmt.Errorf("%d segments, more than a UDH can number", len(segments))
+	}
+	return c.sendParts(&base, parts)
+}
 
+// sendParts submits UDH-prefixed parts of msg in order, pacing them, and
+// returns the message IDs of the parts sent
+func (c *Client) sendParts(msg *SMSMessage, parts [][]byte) ([]string, error) {
+	if !c.drain.enter() {
+		return nil, ErrShuttingDown
+	}
+	defer c.drain.leave()
+
+	partCount := len(parts)
+	messageIDs := make([]string, 0, partCount)
+	for i, part := range parts {
 		// Create message part
-		partMsg := &SMSMessage{
-			SourceAddr:            msg.SourceAddr,
-			DestAddr:              msg.DestAddr,
-			Message:               msg.Message[start:end],
-			DataCoding:            msg.DataCoding,
-			IsUnicode:             msg.IsUnicode,
-			IsBinary:              msg.IsBinary,
-			RequestDeliveryReport: msg.RequestDeliveryReport,
+		partMsg := *msg
+		partMsg.Message = part
+		partMsg.UsePayload = false
+		partMsg.udhi = true
+		if i < partCount-1 {
+			partMsg.MoreMessagesToSend = true
 		}
-
-		// Send message part
-		messageID, err := c.SendSMS(partMsg)
-		if err != nil {
-			return "", fmt.Errorf("failed to send part %d/%d: %w", i+1, partCount, err)
+		if i > 0 {
+			// SendDeadline and the destination limit apply to the first
+			// part only: dropping later ones would leave a message the
+			// handset cannot reassemble
+			partMsg.SendDeadline = time.Time{}
+			partMsg.continuation = true
 		}
 
-		// Store the ID of the first message part
-		if i == 0 {
-			firstMessageID = messageID
+		// Send message part; once started, a shutdown lets the rest
+		// follow until its deadline
+		if c.drain.aborted() {
+			c.drain.abandon(partCount - i)
+			return messageIDs, fmt.Errorf("failed to send part %d/%d: %w", i+1, partCount, ErrShuttingDown)
+		}
+		err := c.awaitBind()
+		var messageID string
+		if err == nil {
+			messageID, _, err = c.submitOne(SUBMIT_SM, &partMsg)
+		}
+		if err != nil {
+			return messageIDs, fmt.Errorf("failed to send part %d/%d: %w", i+1, partCount, err)
 		}
+		messageIDs = append(messageIDs, messageID)
 
 		// Add a delay between message parts to avoid throttling
 		if i < partCount-1 {
-			time.Sleep(200 * time.Millisecond)
+			c.clock.Sleep(200 * time.Millisecond)
 		}
 	}
+	return messageIDs, nil
+}
 
-	return firstMessageID, nil
+// charset is the encoding of msg's content
+func (msg *SMSMessage) charset() Charset {
+	switch {
+	case msg.IsUnicode:
+		return CharsetUCS2
+	case msg.IsBinary:
+		return CharsetBinary
+	}
+	return CharsetOf(msg.DataCoding)
+}
+
+// singlePartLength is the most content msg can carry in one short_message
+func (c *Client) singlePartLength(msg *SMSMessage) int {
+	maxLength := 140 // octets
+	if cs := msg.charset(); cs == CharsetGSM7 || cs == CharsetASCII {
+		maxLength = 160 // 7-bit characters
+	}
+	return min(maxLength, c.maxShortMessageOctets)
+}
+
+// segmentMessage splits msg.Message according to its encoding, keeping each
+// part within the client's short_message limit. With word boundaries enabled
+// text is split after spaces, unless that would take more parts.
+func (c *Client) segmentMessage(msg *SMSMessage, refNum byte) [][]byte {
+	room := c.maxShortMessageOctets - udhConcatLength
+
+	var size int
+	var cut func([]byte) int
+	var space []byte
+	switch msg.charset() {
+	case CharsetUCS2:
+		size, cut, space = min(ucs2SegmentOctets, room&^1), ucs2Cut, []byte{0x00, ' '}
+	case CharsetGSM7, CharsetASCII:
+		size, cut, space = min(gsm7SegmentOctets, room), gsm7Cut, []byte{' '}
+	case CharsetLatin1:
+		size, space = min(binarySegmentOctets, room), []byte{' '}
+	default:
+		size = min(binarySegmentOctets, room)
+	}
+
+	chunks := split(msg.Message, size, cut)
+	if c.wordBoundaries && space != nil {
+		if words := split(msg.Message, size, wordCut(cut, space)); len(words) <= len(chunks) {
+			chunks = words
+		}
+	}
+	return addConcatUDH(chunks, refNum)
+}
+
+// EffectiveTPS returns the rate the limiter currently allows, which drops
+// below the configured rate while the SMSC reports throttling. It is zero
+// when no rate limit is configured.
+func (c *Client) EffectiveTPS() float64 {
+	if c.limiter == nil {
+		return 0
+	}
+	return c.limiter.rate()
+}
+
+// MaxShortMessageOctets returns the largest short_message the client sends
+func (c *Client) MaxShortMessageOctets() int {
+	return c.maxShortMessageOctets
 }
 
 // Disconnect closes the connection to the SMPP server
 func (c *Client) Disconnect() error {
-	if c.bound {
-		// Send unbind command
+	return c.DisconnectContext(context.Background())
+}
+
+// Unbind ends the SMPP session but keeps the connection open, so Bind can
+// start a new session on it
+func (c *Client) Unbind() error {
+	if c.State() != StateBound {
+		return ErrNotBound
+	}
+	if err := c.state.transition(StateUnbinding); err != nil {
+		return err
+	}
+	c.stopKeepalive()
+
+	resp, err := c.sendPDU(newPDU(UNBIND, c.nextSequence()))
+	if err != nil {
+		// The session state is unknown, so do not leave the socket in use
+		c.mu.Lock()
+		c.conn.close()
+		c.mu.Unlock()
+		c.state.transition(StateDisconnected)
+		return fmt.Errorf("unbind: %w", err)
+	}
+	if err := c.state.transition(StateOpen); err != nil {
+		return err
+	}
+	if resp.commandStatus != ESME_ROK {
+		return fmt.Errorf("unbind: %w", &StatusError{resp.commandStatus})
+	}
+	return nil
+}
+
+// Bind starts a new session on a connection left open by Unbind, using
+// the client's credentials and bind parameters
+func (c *Client) Bind() error {
+	if c.State() != StateOpen {
+		return errors.New("no open unbound connection")
+	}
+	if err := c.state.transition(StateBinding); err != nil {
+		return err
+	}
+
+	err := c.bind()
+	if err == nil {
+		return nil
+	}
+	if isConnectionLost(err) {
+		c.connectionLost(err)
+	} else {
+		c.state.transition(StateOpen)
+	}
+	return fmt.Errorf("bind: %w", err)
+}
+
+// DisconnectContext waits for submits in flight, then unbinds and closes
+// the connection, all bounded by ctx; see Shutdown. If ctx is done before
+// unbind_resp arrives the socket is closed immediately and ctx.Err() is
+// returned.
+func (c *Client) DisconnectContext(ctx context.Context) error {
+	_, err := c.Shutdown(ctx)
+	return err
+}
+
+// disconnect unbinds and closes the connection, bounding the unbind
+// handshake by ctx
+func (c *Client) disconnect(ctx context.Context) error {
+	c.stopKeepalive()
+
+	if c.state.transition(StateUnbinding) == nil {
+		stop := c.conn.closeOnDone(ctx)
+
+		// Send unbind command; a socket closed by ctx is not a lost connection
 		pdu := newPDU(UNBIND, c.nextSequence())
-		_, err := c.sendPDU(pdu)
+		_, err := c.roundTrip(pdu)
+		stop()
 		if err != nil {
+			c.mu.Lock()
 			c.conn.close()
+			c.mu.Unlock()
+			c.state.transition(StateDisconnected)
+			if ctx.Err() != nil {
+				return fmt.Errorf("unbind: %w", ctx.Err())
+			}
 			return err
 		}
-		c.bound = false
 	}
 
-	return c.conn.close()
+	c.mu.Lock()
+	err := c.conn.close()
+	c.mu.Unlock()
+	c.state.transition(StateDisconnected)
+	return err
 }
 
-// nextSequence returns the next sequence number for PDUs
+// nextSequence returns the next sequence number for PDUs, skipping numbers
+// still awaiting a response so that none is reused after wraparound
 func (c *Client) nextSequence() uint32 {
-	seq := c.sequenceNum
-	c.sequenceNum++
-	if c.sequenceNum > 0x7FFFFFFF {
-		c.sequenceNum = 1
+	d := c.dispatch.Load()
+	for range maxSequenceSkips {
+		seq := c.sequence.Next()
+		if d == nil || !d.isPending(seq) {
+			return seq
+		}
 	}
-	return seq
+	// The generator keeps returning pending numbers; register refuses them
+	return c.sequence.Next()
+}
+
+// BeforeSend installs a hook that receives the serialized body of every
+// outgoing PDU and returns the body to put on the wire; command_length is
+// recomputed afterwards. It is an escape hatch for SMSC bugs the library
+// cannot anticipate: the hook is trusted completely, and a wrong edit
+// produces a corrupt PDU. Set it before Connect; nil removes it.
+func (c *Client) BeforeSend(fn func(commandID uint32, body []byte) []byte) {
+	c.beforeSend = fn
+}
+
+// nextConcatRef returns the reference number for the next concatenated message
+func (c *Client) nextConcatRef() byte {
+	return byte(c.concatRef.Add(1))
+}
+
+// OnConnectionLost registers fn to be called when the SMSC closes or resets
+// the connection, or unbinds the session. By then the client is already
+// unbound and disconnected, so fn may call Connect to recover.
+func (c *Client) OnConnectionLost(fn func(err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onConnectionLost = fn
 }
 
-// sendPDU sends a PDU and waits for the response
+// sendPDU sends a PDU and waits for the response. If the peer has gone away
+// the client is moved to the disconnected state instead of being left with a
+// dead socket.
 func (c *Client) sendPDU(pdu *pdu) (*pdu, error) {
-	err := c.conn.writePDU(pdu)
-	if err != nil {
+	resp, err := c.roundTrip(pdu)
+	if err != nil && isConnectionLost(err) {
+		c.connectionLost(err)
+	}
+	return resp, err
+}
+
+// connectionLost drops the dead connection and notifies OnConnectionLost,
+// unless the client was unbinding and so closing the connection itself
+func (c *Client) connectionLost(err error) {
+	c.mu.Lock()
+	if c.conn.conn == nil {
+		c.mu.Unlock()
+		return // already handled
+	}
+	handler := c.onConnectionLost
+	if c.State() == StateUnbinding {
+		handler = nil
+	}
+	c.stopKeepalive()
+	c.conn.close()
+	c.state.transition(StateDisconnected)
+	c.mu.Unlock()
+
+	if handler != nil {
+		handler(err)
+	}
+}
+
+// roundTrip writes a PDU and reads its response. The write and the read
+// happen under c.mu so concurrent callers never interleave on the wire or
+// consume each other's responses. When a read loop runs only the write
+// takes c.mu and the loop delivers the response.
+func (c *Client) roundTrip(pdu *pdu) (*pdu, error) {
+	return c.roundTripWithin(pdu, c.conn.readTimeout)
+}
+
+// roundTripWithin is roundTrip waiting up to timeout for the response
+func (c *Client) roundTripWithin(pdu *pdu, timeout time.Duration) (*pdu, error) {
+	if d := c.dispatch.Load(); d != nil {
+		return c.dispatchedRoundTrip(d, pdu, timeout)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.write(pdu); err != nil {
 		return nil, err
 	}
 
-	// Read response
-	return c.conn.readPDU()
+	for {
+		resp, err := c.readResponse(timeout)
+		if err != nil {
+			return nil, err
+		}
+		if resp.sequenceNumber == pdu.sequenceNumber {
+			return resp, nil
+		}
+		c.orphanedResponse(resp)
+	}
+}
+
+// orphanedResponse records a response matching no outstanding request,
+// either stale after an abandoned request or a sequence the SMSC got wrong.
+// The response is dropped and reading continues.
+func (c *Client) orphanedResponse(resp *pdu) {
+	c.counters.orphanedResponses.Add(1)
+	c.logger.Warn("smpp: dropping response to unknown request",
+		"command", commandName(resp.commandID), "sequence", resp.sequenceNumber, "status", resp.commandStatus)
+}
+
+// write applies the BeforeSend hook and writes a PDU. Must be called with
+// c.mu held.
+func (c *Client) write(pdu *pdu) error {
+	if c.beforeSend != nil {
+		pdu.body = c.beforeSend(pdu.commandID, pdu.body)
+	}
+	return c.conn.writePDU(pdu)
+}
+
+// readResponse reads until a response PDU arrives, answering any requests
+// the SMSC sends in between. Each read waits up to timeout. Must be called
+// with c.mu held.
+func (c *Client) readResponse(timeout time.Duration) (*pdu, error) {
+	for {
+		p, err := c.conn.readPDU(timeout)
+		if err != nil {
+			return nil, err
+		}
+		if p.isResponse() {
+			return p, nil
+		}
+		if err := c.handleRequest(p); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// handleRequest answers a request PDU initiated by the SMSC. Must be called
+// with c.mu held.
+func (c *Client) handleRequest(req *pdu) error {
+	switch req.commandID {
+	case ENQUIRE_LINK:
+		return c.sendResponse(req.commandID, req.sequenceNumber, ESME_ROK, nil)
+
+	case DELIVER_SM:
+		// Acknowledged once the OnDeliverSM handler has run
+		return c.queueDeliverSM(req)
+
+	case ALERT_NOTIFICATION:
+		c.handleAlertNotification(req)
+		return nil
+
+	case UNBIND:
+		c.state.transition(StateDisconnected)
+		c.sendResponse(req.commandID, req.sequenceNumber, ESME_ROK, nil)
+		return errUnboundBySMSC
+
+	default:
+		if handled, err := c.handleVendorCommand(req); handled {
+			return err
+		}
+		return c.sendResponse(GENERIC_NACK, req.sequenceNumber, ESME_RINVCMDID, nil)
+	}
+}
+
+// SendResponse writes a response PDU for commandID (the request or the
+// response command ID) echoing sequence, for applications that answer SMSC
+// requests themselves, such as gateways and SMSC emulators. body is the
+// encoded mandatory and optional parameters.
+func (c *Client) SendResponse(commandID, sequence, status uint32, body []byte) error {
+	c.mu.Lock()
+	err := c.sendResponse(commandID, sequence, status, body)
+	c.mu.Unlock()
+
+	if err != nil && isConnectionLost(err) {
+		c.connectionLost(err)
+	}
+	return err
+}
+
+// sendResponse is SendResponse for callers already holding c.mu
+func (c *Client) sendResponse(commandID, sequence, status uint32, body []byte) error {
+	resp := newPDU(commandID|responseBit, sequence)
+	resp.commandStatus = status
+	resp.write(body)
+	return c.conn.writePDU(resp)
 }
 
 const (
+	GENERIC_NACK          uint32 = 0x80000000
+	BIND_RECEIVER         uint32 = 0x00000001
+	BIND_RECEIVER_RESP    uint32 = 0x80000001
 	BIND_TRANSMITTER      uint32 = 0x00000002
 	BIND_TRANSMITTER_RESP uint32 = 0x80000002
+	QUERY_SM              uint32 = 0x00000003
+	QUERY_SM_RESP         uint32 = 0x80000003
 	SUBMIT_SM             uint32 = 0x00000004
 	SUBMIT_SM_RESP        uint32 = 0x80000004
+	DELIVER_SM            uint32 = 0x00000005
+	DELIVER_SM_RESP       uint32 = 0x80000005
 	UNBIND                uint32 = 0x00000006
 	UNBIND_RESP           uint32 = 0x80000006
+	REPLACE_SM            uint32 = 0x00000007
+	REPLACE_SM_RESP       uint32 = 0x80000007
+	BIND_TRANSCEIVER      uint32 = 0x00000009
+	BIND_TRANSCEIVER_RESP uint32 = 0x80000009
+	ENQUIRE_LINK          uint32 = 0x00000015
+	ENQUIRE_LINK_RESP     uint32 = 0x80000015
+	ALERT_NOTIFICATION    uint32 = 0x00000102
+	DATA_SM               uint32 = 0x00000103
+	DATA_SM_RESP          uint32 = 0x80000103
 )
@@ -0,0 +1,58 @@
+package smpp
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultQueueFullCooldown is how long submits pause after ESME_RMSGQFUL
+const defaultQueueFullCooldown = time.Second
+
+// backpressure pauses submits for a cooldown after the SMSC reports its
+// message queue full
+type backpressure struct {
+	mu      sync.Mutex
+	until   time.Time
+	handler func(cooldown time.Duration)
+}
+
+// queueFull starts a cooldown of d from now and returns the handler to
+// notify, if any
+func (b *backpressure) queueFull(now time.Time, d time.Duration) func(time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.until = now.Add(d)
+	return b.handler
+}
+
+// wait blocks until any cooldown in progress has passed
+func (b *backpressure) wait(clock Clock) {
+	b.mu.Lock()
+	until := b.until
+	b.mu.Unlock()
+	if d := until.Sub(clock.Now()); d > 0 {
+		clock.Sleep(d)
+	}
+}
+
+// OnQueueFull sets a function called when a submit is refused with
+// ESME_RMSGQFUL, with the cooldown for which further submits are paused.
+// It runs on its own goroutine. The refused submit itself returns a
+// *StatusError with Status ESME_RMSGQFUL.
+func (c *Client) OnQueueFull(fn func(cooldown time.Duration)) {
+	c.backpressure.mu.Lock()
+	defer c.backpressure.mu.Unlock()
+	c.backpressure.handler = fn
+}
+
+// queueFull reacts to ESME_RMSGQFUL: pause submits, lower the rate limit
+// and notify OnQueueFull
+func (c *Client) queueFull() {
+	if c.limiter != nil {
+		c.limiter.throttled()
+	}
+	c.logger.Warn("smpp: SMSC message queue full, pausing submits", "cooldown", c.queueFullCooldown)
+	if handler := c.backpressure.queueFull(c.clock.Now(), c.queueFullCooldown); handler != nil {
+		go handler(c.queueFullCooldown)
+	}
+}
@@ -0,0 +1,60 @@
+package smpp
+
+import (
+	"errors"
+	"net"
+)
+
+// SubmitResult is the outcome of one message in a batch
+type SubmitResult struct {
+	MessageID string
+	Err       error
+
+	// Retryable marks a failure worth submitting again later: a transient
+	// command_status (see StatusError.Retryable), a lost connection or
+	// missing bind, or a client-side limit. Validation errors and
+	// permanent SMSC rejections are not retryable.
+	Retryable bool
+}
+
+// isRetryable classifies a submit error for SubmitResult.Retryable
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Retryable()
+	}
+	var ne net.Error
+	return errors.As(err, &ne) ||
+		isConnectionLost(err) ||
+		errors.Is(err, ErrNotBound) ||
+		errors.Is(err, ErrDestinationThrottled) ||
+		errors.Is(err, ErrShuttingDown)
+}
+
+// SendBatch submits msgs pipelined over the connection and returns one
+// result per message, in order. Each message is encoded from its own
+// fields, so a batch may mix sender IDs and source TON/NPI; the rate and
+// per-destination limits apply to every message as for SendSMS. The
+// returned error is the transport error that cut the batch short, if any.
+func (c *Client) SendBatch(msgs []*SMSMessage) ([]SubmitResult, error) {
+	if err := c.awaitBind(); err != nil {
+		return nil, err
+	}
+
+	p := c.Pipeline()
+	futures := make([]*SubmitFuture, len(msgs))
+	for i, msg := range msgs {
+		futures[i] = p.Submit(msg)
+	}
+	err := p.Wait()
+
+	results := make([]SubmitResult, len(msgs))
+	for i, f := range futures {
+		results[i].MessageID, results[i].Err = f.Result()
+		results[i].Retryable = isRetryable(results[i].Err)
+	}
+	return results, err
+}
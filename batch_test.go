@@ -0,0 +1,38 @@
+package smpp
+
+import "testing"
+
+func TestSendBatchMixedSources(t *testing.T) {
+	submits := make(chan *DeliverSM, 3)
+	smsc := newTestSMSC(t, recordSubmits(submits))
+	c := smsc.client()
+	connect(t, c)
+
+	sources := []Address{
+		{TON: 5, NPI: 0, Addr: "Shop"},
+		{TON: 1, NPI: 1, Addr: "998901234567"},
+		{TON: 3, NPI: 9, Addr: "1234"},
+	}
+	msgs := make([]*SMSMessage, len(sources))
+	for i, src := range sources {
+		msgs[i] = &SMSMessage{
+			SourceAddr:    src.Addr,
+			SourceAddrTON: src.TON,
+			SourceAddrNPI: src.NPI,
+			DestAddr:      "998907654321",
+			Message:       []byte("hi"),
+		}
+	}
+	results, err := c.SendBatch(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, src := range sources {
+		if results[i].Err != nil {
+			t.Fatalf("message %d: %v", i, results[i].Err)
+		}
+		if got := (<-submits).Source; got != src {
+			t.Fatalf("submit %d source = %+v, want %+v", i, got, src)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package smpp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errReceiverSubmit reports a submit attempted on a receiver session
+var errReceiverSubmit = errors.New("a receiver session cannot submit messages")
+
+// BindMode selects the kind of session the client binds as
+type BindMode int
+
+const (
+	BindTransmitter BindMode = iota // submits messages; the default
+	BindReceiver                    // receives deliver_sm, cannot submit
+	BindTransceiver                 // submits and receives on one connection
+)
+
+func (m BindMode) String() string {
+	switch m {
+	case BindTransmitter:
+		return "transmitter"
+	case BindReceiver:
+		return "receiver"
+	case BindTransceiver:
+		return "transceiver"
+	default:
+		return fmt.Sprintf("BindMode(%d)", int(m))
+	}
+}
+
+// commandID returns the bind command requesting a session of mode m
+func (m BindMode) commandID() uint32 {
+	switch m {
+	case BindReceiver:
+		return BIND_RECEIVER
+	case BindTransceiver:
+		return BIND_TRANSCEIVER
+	default:
+		return BIND_TRANSMITTER
+	}
+}
+
+// canSubmit reports whether a session of mode m may send submit_sm
+func (m BindMode) canSubmit() bool {
+	return m != BindReceiver
+}
+
+// readsContinuously reports whether a session of mode m must keep reading
+// the connection between requests, as the SMSC sends PDUs on its own
+func (m BindMode) readsContinuously() bool {
+	return m == BindReceiver || m == BindTransceiver
+}
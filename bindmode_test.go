@@ -0,0 +1,81 @@
+package smpp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// recordBinds returns an SMSC handler reporting the command ID of each
+// bind before the default reply
+func recordBinds(binds chan uint32) func(*smscConn, *pdu) bool {
+	return func(conn *smscConn, p *pdu) bool {
+		switch p.commandID {
+		case BIND_TRANSMITTER, BIND_RECEIVER, BIND_TRANSCEIVER:
+			binds <- p.commandID
+		}
+		return false
+	}
+}
+
+func TestReceiverBind(t *testing.T) {
+	binds := make(chan uint32, 1)
+	smsc := newTestSMSC(t, recordBinds(binds))
+	c := smsc.client(WithBindMode(BindReceiver))
+	received := make(chan *DeliverSM, 1)
+	c.OnDeliverSM(func(d *DeliverSM) { received <- d })
+	connect(t, c)
+	conn := smsc.conn()
+
+	if got := <-binds; got != BIND_RECEIVER {
+		t.Fatalf("bound with %s, want bind_receiver", commandName(got))
+	}
+	conn.send(DELIVER_SM, ESME_ROK, 1, deliverSMBody("998901234567", "1234", 0, "hello"))
+	select {
+	case d := <-received:
+		if string(d.Message) != "hello" {
+			t.Fatalf("message = %q", d.Message)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("deliver_sm not read on the receiver session")
+	}
+
+	_, err := c.SendSMS(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+	if !errors.Is(err, errReceiverSubmit) {
+		t.Fatalf("submit on a receiver: %v", err)
+	}
+}
+
+func TestTransceiverBind(t *testing.T) {
+	binds := make(chan uint32, 1)
+	resps := make(chan *pdu, 1)
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		recordBinds(binds)(conn, p)
+		return deliverResps(resps)(conn, p)
+	})
+	c := smsc.client(WithBindMode(BindTransceiver))
+	received := make(chan *DeliverSM, 1)
+	c.OnDeliverSM(func(d *DeliverSM) { received <- d })
+	connect(t, c)
+	conn := smsc.conn()
+
+	if got := <-binds; got != BIND_TRANSCEIVER {
+		t.Fatalf("bound with %s, want bind_transceiver", commandName(got))
+	}
+
+	// A deliver_sm arriving ahead of a submit_sm_resp must not be taken
+	// for it
+	submitted := make(chan error, 1)
+	go func() {
+		_, err := c.SendSMS(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+		submitted <- err
+	}()
+	conn.send(DELIVER_SM, ESME_ROK, 1000, deliverSMBody("998901234567", "1234", 0, "hello"))
+	if err := <-submitted; err != nil {
+		t.Fatal(err)
+	}
+	<-received
+	if resp := awaitResp(t, resps); resp.sequenceNumber != 1000 || resp.commandStatus != ESME_ROK {
+		t.Fatalf("deliver_sm_resp seq %d status %#x", resp.sequenceNumber, resp.commandStatus)
+	}
+}
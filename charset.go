@@ -0,0 +1,104 @@
+package smpp
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// Charset is the text encoding a data_coding value selects
+type Charset int
+
+const (
+	CharsetGSM7   Charset = iota // GSM 03.38 default alphabet
+	CharsetASCII                 // IA5 / ASCII
+	CharsetLatin1                // ISO-8859-1
+	CharsetUCS2                  // UCS-2, decoded as UTF-16BE
+	CharsetBinary                // 8-bit data or an alphabet this package does not decode
+)
+
+func (cs Charset) String() string {
+	switch cs {
+	case CharsetGSM7:
+		return "GSM7"
+	case CharsetASCII:
+		return "ASCII"
+	case CharsetLatin1:
+		return "Latin-1"
+	case CharsetUCS2:
+		return "UCS2"
+	default:
+		return "binary"
+	}
+}
+
+// CharsetOf interprets a data_coding value per SMPP 3.4 and GSM 03.38.
+// Compressed content and alphabets without a decoder here (JIS, Cyrillic,
+// Hebrew, ...) are reported as CharsetBinary.
+func CharsetOf(dataCoding byte) Charset {
+	switch {
+	case dataCoding == 0x00:
+		return CharsetGSM7 // SMSC default alphabet
+	case dataCoding == 0x01:
+		return CharsetASCII
+	case dataCoding == DataCodingLatin1:
+		return CharsetLatin1
+	case dataCoding == DataCodingUCS2:
+		return CharsetUCS2
+	case dataCoding < 0x10:
+		return CharsetBinary // 8-bit, JIS, Cyrillic, Hebrew, pictogram, ...
+	case dataCoding < 0x40: // general data coding group
+		if dataCoding&DataCodingCompressed != 0 {
+			return CharsetBinary
+		}
+		return alphabet(dataCoding >> 2 & 0x03)
+	case dataCoding >= 0xC0 && dataCoding < 0xE0: // message waiting, GSM 7-bit
+		return CharsetGSM7
+	case dataCoding >= 0xE0 && dataCoding < 0xF0: // message waiting, UCS2
+		return CharsetUCS2
+	case dataCoding >= 0xF0: // data coding/message class
+		if dataCoding&0x04 != 0 {
+			return CharsetBinary
+		}
+		return CharsetGSM7
+	default:
+		return CharsetBinary
+	}
+}
+
+// alphabet maps the two GSM 03.38 alphabet bits to a Charset
+func alphabet(bits byte) Charset {
+	switch bits {
+	case 0:
+		return CharsetGSM7
+	case 2:
+		return CharsetUCS2
+	default:
+		return CharsetBinary
+	}
+}
+
+// DecodeMessage decodes an inbound short_message or message_payload to
+// text according to dataCoding and reports the charset used. GSM 7-bit
+// text is expected unpacked, one septet per octet. Binary content is
+// returned byte for byte.
+func DecodeMessage(dataCoding byte, body []byte) (string, Charset) {
+	cs := CharsetOf(dataCoding)
+	switch cs {
+	case CharsetGSM7:
+		return decodeGSM7(body), cs
+	case CharsetLatin1:
+		runes := make([]rune, len(body))
+		for i, b := range body {
+			runes[i] = rune(b)
+		}
+		return string(runes), cs
+	case CharsetUCS2:
+		units := make([]uint16, len(body)/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(body[2*i:])
+		}
+		return string(utf16.Decode(units)), cs
+	default:
+		return string(body), cs
+	}
+}
@@ -0,0 +1,20 @@
+package smpp
+
+import "time"
+
+// Clock is the time source for the client's own timers: part spacing,
+// queue timeouts, rate limiting, response timeouts on a session with a
+// read loop, and, where present, keepalive and retry backoff. Socket
+// deadlines always use the real clock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// realClock is the Clock backed by package time
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
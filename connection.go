@@ -1,21 +1,65 @@
 package smpp
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"strconv"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
 type connection struct {
 	host           string
 	port           int
+	localAddr      string
 	conn           net.Conn
+	sock           atomic.Pointer[net.Conn] // conn, for closeOnDone to read without the client lock
 	connectTimeout time.Duration
 	readTimeout    time.Duration
+	trace          ConnectTrace
+	tlsState       *tls.ConnectionState // nil for plain connections
+	recorder       *recorder            // nil unless WithSessionRecorder
+	recent         *pduRing             // nil unless WithRecentPDUs
+	bytesSent      atomic.Uint64        // PDU octets written, across connections
+	bytesReceived  atomic.Uint64        // PDU octets read, across connections
+}
+
+// ConnectTrace breaks down the time spent in the most recent Connect
+type ConnectTrace struct {
+	DNS  time.Duration // resolving the SMSC host name
+	TCP  time.Duration // TCP handshake
+	TLS  time.Duration // TLS handshake, zero for plain connections
+	Bind time.Duration // bind request to bind response
+}
+
+// errUnboundBySMSC reports that the SMSC ended the session with unbind
+var errUnboundBySMSC = errors.New("SMSC unbound the session")
+
+// errBadCommandLength reports a PDU header whose command_length is out of
+// range; the stream cannot be resynchronized after it
+var errBadCommandLength = errors.New("invalid command_length")
+
+// errBrokenStream reports a write that failed, possibly part way through a
+// PDU, after which the peer can no longer parse the stream
+var errBrokenStream = errors.New("write failed, connection unusable")
+
+// isConnectionLost reports whether err means the peer closed or reset the
+// connection, or ended the session, rather than a transient failure
+func isConnectionLost(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, errUnboundBySMSC) ||
+		errors.Is(err, errBadCommandLength) ||
+		errors.Is(err, errBrokenStream)
 }
 
 func newConnection(host string, port int, connectTimeout, readTimeout time.Duration) *connection {
@@ -27,33 +71,126 @@ func newConnection(host string, port int, connectTimeout, readTimeout time.Durat
 	}
 }
 
-func (c *connection) connect() error {
-	addr := fmt.Sprintf("%s:%d", c.host, c.port)
-	dialer := net.Dialer{Timeout: c.connectTimeout}
+// dialer returns a dialer bound to localAddr, if one is configured. The
+// local address may be a bare IP or an IP:port pair.
+func (c *connection) dialer() (*net.Dialer, error) {
+	dialer := &net.Dialer{Timeout: c.connectTimeout}
+	if c.localAddr == "" {
+		return dialer, nil
+	}
+
+	if ip := net.ParseIP(c.localAddr); ip != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+		return dialer, nil
+	}
+	addr, err := net.ResolveTCPAddr("tcp", c.localAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local address %q: %w", c.localAddr, err)
+	}
+	dialer.LocalAddr = addr
+	return dialer, nil
+}
+
+// dialError adds the local address to dial errors when one is configured
+func (c *connection) dialError(err error) error {
+	if c.localAddr == "" {
+		return err
+	}
+	return fmt.Errorf("dial from local address %s: %w", c.localAddr, err)
+}
+
+// ConnectError reports the stage of the connect sequence that failed:
+// "dns", "tcp", "tls" or "bind". When the context given to ConnectContext
+// ends first, Err is the context's error.
+type ConnectError struct {
+	Stage string
+	Err   error
+}
+
+func (e *ConnectError) Error() string {
+	return e.Stage + ": " + e.Err.Error()
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// dial resolves the SMSC host and opens a TCP connection to the first
+// address that accepts, recording DNS and TCP timings in c.trace
+func (c *connection) dial(ctx context.Context) (net.Conn, error) {
+	c.trace = ConnectTrace{}
+	dialer, err := c.dialer()
+	if err != nil {
+		return nil, &ConnectError{"tcp", err}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.connectTimeout)
+	defer cancel()
+
+	start := time.Now()
+	hosts, err := net.DefaultResolver.LookupHost(ctx, c.host)
+	c.trace.DNS = time.Since(start)
+	if err != nil {
+		return nil, &ConnectError{"dns", err}
+	}
+
+	start = time.Now()
+	defer func() { c.trace.TCP = time.Since(start) }()
+
+	port := strconv.Itoa(c.port)
+	for _, host := range hosts {
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, &ConnectError{"tcp", c.dialError(err)}
+}
 
-	conn, err := dialer.Dial("tcp", addr)
+func (c *connection) connect(ctx context.Context) error {
+	conn, err := c.dial(ctx)
 	if err != nil {
 		return err
 	}
 
+	c.tlsState = nil
 	c.conn = conn
+	c.sock.Store(&conn)
 	return nil
 }
 
-func (c *connection) connectTLS(config *tls.Config) error {
-	addr := fmt.Sprintf("%s:%d", c.host, c.port)
-	dialer := net.Dialer{Timeout: c.connectTimeout}
+func (c *connection) connectTLS(ctx context.Context, config *tls.Config) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
 
 	if config == nil {
-		config = &tls.Config{InsecureSkipVerify: true}
+		config = &tls.Config{}
+	}
+	if config.ServerName == "" {
+		config = config.Clone()
+		config.ServerName = c.host
 	}
 
-	conn, err := tls.DialWithDialer(&dialer, "tcp", addr, config)
+	ctx, cancel := context.WithTimeout(ctx, c.connectTimeout)
+	defer cancel()
+
+	start := time.Now()
+	tlsConn := tls.Client(conn, config)
+	err = tlsConn.HandshakeContext(ctx)
+	c.trace.TLS = time.Since(start)
 	if err != nil {
-		return err
+		conn.Close()
+		return &ConnectError{"tls", err}
 	}
 
-	c.conn = conn
+	state := tlsConn.ConnectionState()
+	c.tlsState = &state
+	var sock net.Conn = tlsConn
+	c.conn = sock
+	c.sock.Store(&sock)
 	return nil
 }
 
@@ -64,9 +201,23 @@ func (c *connection) close() error {
 
 	err := c.conn.Close()
 	c.conn = nil
+	c.sock.Store(nil)
 	return err
 }
 
+// closeOnDone closes the socket as soon as ctx is done, unblocking any
+// pending read or write. Calling the returned function stops the watch.
+func (c *connection) closeOnDone(ctx context.Context) (stop func() bool) {
+	sock := c.sock.Load()
+	if sock == nil {
+		return func() bool { return false }
+	}
+	conn := *sock
+	return context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+}
+
 func (c *connection) writePDU(p *pdu) error {
 	if c.conn == nil {
 		return errors.New("not connected")
@@ -78,43 +229,38 @@ func (c *connection) writePDU(p *pdu) error {
 		return err
 	}
 
-	// Calculate PDU length
-	p.commandLength = uint32(16 + len(p.body))
-
-	// Write PDU header
-	headerBuf := make([]byte, 16)
-	binary.BigEndian.PutUint32(headerBuf[0:4], p.commandLength)
-	binary.BigEndian.PutUint32(headerBuf[4:8], p.commandID)
-	binary.BigEndian.PutUint32(headerBuf[8:12], p.commandStatus)
-	binary.BigEndian.PutUint32(headerBuf[12:16], p.sequenceNumber)
-
-	_, err = c.conn.Write(headerBuf)
-	if err != nil {
-		return err
-	}
-
-	if len(p.body) > 0 {
-		_, err = c.conn.Write(p.body)
-		if err != nil {
-			return err
-		}
+	// Write header and body in one go
+	raw := p.encode()
+	c.recorder.record(recordOutbound, raw)
+	c.recent.add(true, p)
+	if n, err := c.conn.Write(raw); err != nil {
+		// Even a timeout may have sent part of the PDU, so the stream
+		// cannot be trusted any more
+		c.bytesSent.Add(uint64(n))
+		return fmt.Errorf("%w (%d of %d bytes sent): %w", errBrokenStream, n, len(raw), err)
 	}
-
+	c.bytesSent.Add(uint64(len(raw)))
 	return nil
 }
 
-func (c *connection) readPDU() (*pdu, error) {
+// readPDU reads a PDU from the connection, waiting up to timeout
+func (c *connection) readPDU(timeout time.Duration) (*pdu, error) {
 	if c.conn == nil {
 		return nil, errors.New("not connected")
 	}
+	return c.readPDUFrom(c.conn, time.Now().Add(timeout))
+}
 
-	err := c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+// readPDUFrom reads a PDU from conn, which the read loop captures so it
+// needs no lock, waiting until deadline; the zero deadline waits forever
+func (c *connection) readPDUFrom(conn net.Conn, deadline time.Time) (*pdu, error) {
+	err := conn.SetReadDeadline(deadline)
 	if err != nil {
 		return nil, err
 	}
 
 	headerBuf := make([]byte, 16)
-	_, err = io.ReadFull(c.conn, headerBuf)
+	_, err = io.ReadFull(conn, headerBuf)
 	if err != nil {
 		return nil, err
 	}
@@ -125,11 +271,16 @@ func (c *connection) readPDU() (*pdu, error) {
 	p.commandStatus = binary.BigEndian.Uint32(headerBuf[8:12])
 	p.sequenceNumber = binary.BigEndian.Uint32(headerBuf[12:16])
 
+	// Refuse lengths that would underflow or allocate without bound
+	if p.commandLength < pduHeaderLength || p.commandLength > maxPDULength {
+		return nil, fmt.Errorf("%w: %d", errBadCommandLength, p.commandLength)
+	}
+
 	// Read PDU body
 	bodyLength := p.commandLength - 16
 	if bodyLength > 0 {
 		p.body = make([]byte, bodyLength)
-		_, err = io.ReadFull(c.conn, p.body)
+		_, err = io.ReadFull(conn, p.body)
 		if err != nil {
 			return nil, err
 		}
@@ -137,5 +288,8 @@ func (c *connection) readPDU() (*pdu, error) {
 		p.body = []byte{}
 	}
 
+	c.bytesReceived.Add(uint64(p.commandLength))
+	c.recorder.record(recordInbound, p.encode())
+	c.recent.add(false, p)
 	return p, nil
 }
@@ -0,0 +1,77 @@
+package smpp
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stallingConn passes the first after bytes written through, then blocks
+// each write until its deadline, like a peer that stopped reading part way
+// through a PDU
+type stallingConn struct {
+	net.Conn
+	after int
+
+	mu       sync.Mutex
+	written  int
+	deadline time.Time
+}
+
+func (c *stallingConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline = t
+	c.mu.Unlock()
+	return c.Conn.SetWriteDeadline(t)
+}
+
+func (c *stallingConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	n := min(len(b), max(c.after-c.written, 0))
+	c.written += n
+	deadline := c.deadline
+	c.mu.Unlock()
+
+	if n > 0 {
+		if _, err := c.Conn.Write(b[:n]); err != nil {
+			return 0, err
+		}
+	}
+	if n == len(b) {
+		return n, nil
+	}
+	time.Sleep(time.Until(deadline))
+	return n, os.ErrDeadlineExceeded
+}
+
+func TestWriteStalledMidPDU(t *testing.T) {
+	smsc := newTestSMSC(t, nil)
+	lost := make(chan error, 1)
+	c := smsc.client(WithReadTimeout(100 * time.Millisecond))
+	c.OnConnectionLost(func(err error) { lost <- err })
+	connect(t, c)
+
+	// The header gets out, the body does not
+	c.mu.Lock()
+	c.conn.conn = &stallingConn{Conn: c.conn.conn, after: pduHeaderLength}
+	c.mu.Unlock()
+
+	_, err := c.SendSMS(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+	if !errors.Is(err, errBrokenStream) || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("err = %v, want a broken stream after the deadline", err)
+	}
+	select {
+	case err := <-lost:
+		if !errors.Is(err, errBrokenStream) {
+			t.Fatalf("connection lost with %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("half-written connection kept")
+	}
+	if c.State() != StateDisconnected {
+		t.Fatalf("state = %s, want disconnected", c.State())
+	}
+}
@@ -0,0 +1,57 @@
+package smpp
+
+// Standard data_coding values from GSM 03.38 / SMPP 3.4
+const (
+	DataCodingGSM7   byte = 0x00
+	DataCodingLatin1 byte = 0x03
+	DataCodingBinary byte = 0x04
+	DataCodingUCS2   byte = 0x08
+)
+
+// DataCodingCompressed is the general data coding group bit flagging
+// compressed text (GSM 03.38 bit 5). GSM 03.42 compression is not
+// implemented, so such inbound content is only recognised, not decoded.
+const DataCodingCompressed byte = 0x20
+
+// DataCodingMap lists the data_coding byte emitted for each kind of
+// content, for SMSCs whose values deviate from the standard
+type DataCodingMap struct {
+	GSM7   byte
+	Latin1 byte
+	UCS2   byte
+	Binary byte
+}
+
+// DefaultDataCodingMap holds the standard GSM 03.38 values
+var DefaultDataCodingMap = DataCodingMap{
+	GSM7:   DataCodingGSM7,
+	Latin1: DataCodingLatin1,
+	UCS2:   DataCodingUCS2,
+	Binary: DataCodingBinary,
+}
+
+// dataCoding returns the data_coding byte for msg. IsUnicode and IsBinary
+// select UCS2 and Binary; otherwise a standard DataCoding value names the
+// content kind and is translated through the map, while any other value is
+// sent verbatim.
+func (m DataCodingMap) dataCoding(msg *SMSMessage) byte {
+	switch {
+	case msg.IsUnicode:
+		return m.UCS2
+	case msg.IsBinary:
+		return m.Binary
+	}
+
+	switch msg.DataCoding {
+	case DataCodingGSM7:
+		return m.GSM7
+	case DataCodingLatin1:
+		return m.Latin1
+	case DataCodingUCS2:
+		return m.UCS2
+	case DataCodingBinary:
+		return m.Binary
+	default:
+		return msg.DataCoding
+	}
+}
@@ -0,0 +1,51 @@
+package smpp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSendDataSM(t *testing.T) {
+	payloads := make(chan []byte, 1)
+	var refuse atomic.Bool
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID != DATA_SM {
+			return false
+		}
+		if refuse.Load() {
+			conn.reply(p, ESME_RSYSERR, nil)
+			return true
+		}
+		s, err := NewPDUStream(bytes.NewReader(p.encode())).Next()
+		if err != nil {
+			t.Error(err)
+			return false
+		}
+		payload, _ := io.ReadAll(s.Payload)
+		payloads <- payload
+		return false
+	})
+	c := smsc.client()
+	connect(t, c)
+
+	// Short content still goes in message_payload
+	id, err := c.SendDataSM(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(id, "id-") {
+		t.Fatalf("message ID = %q", id)
+	}
+	if payload := <-payloads; !bytes.Equal(payload, []byte("hi")) {
+		t.Fatalf("message_payload = %q", payload)
+	}
+
+	refuse.Store(true)
+	_, err = c.SendDataSM(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+	if err == nil || !strings.HasPrefix(err.Error(), "data_sm failed") {
+		t.Fatalf("err = %v, want a data_sm failure", err)
+	}
+}
@@ -0,0 +1,60 @@
+package smpp
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// dedupKey identifies a message by source, destination and content
+type dedupKey [sha256.Size]byte
+
+func newDedupKey(msg *SMSMessage) dedupKey {
+	h := sha256.New()
+	var n [4]byte
+	for _, field := range [][]byte{[]byte(msg.SourceAddr), []byte(msg.DestAddr), msg.Message} {
+		binary.BigEndian.PutUint32(n[:], uint32(len(field)))
+		h.Write(n[:])
+		h.Write(field)
+	}
+	var k dedupKey
+	h.Sum(k[:0])
+	return k
+}
+
+type dedupEntry struct {
+	messageID string
+	sent      time.Time
+}
+
+// dedup remembers recently submitted messages so an identical resubmit
+// within the window can be suppressed
+type dedup struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries *lru[dedupKey, dedupEntry]
+}
+
+func newDedup(window time.Duration, size int) *dedup {
+	return &dedup{window: window, entries: newLRU[dedupKey, dedupEntry](size)}
+}
+
+// lookup returns the message ID of an identical message sent within the
+// window before now
+func (d *dedup) lookup(k dedupKey, now time.Time) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.entries.get(k)
+	if !ok || now.Sub(e.sent) >= d.window {
+		return "", false
+	}
+	return e.messageID, true
+}
+
+// remember records a message accepted by the SMSC
+func (d *dedup) remember(k dedupKey, messageID string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries.put(k, dedupEntry{messageID: messageID, sent: now})
+}
@@ -0,0 +1,307 @@
+package smpp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+)
+
+// DeliverSM is a deliver_sm from the SMSC: a mobile-originated message, a
+// delivery receipt or an SME acknowledgement, told apart by EsmClass.Type
+type DeliverSM struct {
+	SequenceNumber     uint32
+	ServiceType        string
+	Source             Address
+	Dest               Address
+	EsmClass           EsmClass
+	ProtocolID         byte
+	Priority           byte
+	RegisteredDelivery RegisteredDelivery
+	DataCoding         byte
+
+	// Message is the content: short_message, or message_payload when
+	// short_message is empty, as reported by FromPayload. With
+	// EsmClass.UDHI set it starts with a user data header.
+	Message     []byte
+	FromPayload bool
+
+	SourcePort         *uint16
+	DestPort           *uint16
+	MoreMessagesToSend bool
+	ITSReplyType       *ITSReplyType
+	ITSSessionInfo     *ITSSessionInfo
+
+	// TLVs holds every optional parameter by tag, including those decoded
+	// above
+	TLVs map[uint16][]byte
+
+	// Raw is a copy of the PDU body as received; Message and TLVs point
+	// into it
+	Raw []byte
+}
+
+// Text decodes Message according to DataCoding, skipping any user data
+// header, and reports the charset used
+func (d *DeliverSM) Text() (string, Charset) {
+	body := d.Message
+	if d.EsmClass.UDHI && len(body) > 0 && int(body[0]) < len(body) {
+		body = body[1+int(body[0]):]
+	}
+	return DecodeMessage(d.DataCoding, body)
+}
+
+// parseDeliverSM decodes a deliver_sm body. The body is copied first, so
+// the result does not alias the read buffer.
+func parseDeliverSM(seq uint32, body []byte) (*DeliverSM, error) {
+	raw := append([]byte(nil), body...)
+	r := &bodyReader{b: raw}
+	d := &DeliverSM{SequenceNumber: seq, Raw: raw}
+
+	var err error
+	if d.ServiceType, err = r.cString("service_type", maxServiceTypeLength); err != nil {
+		return nil, err
+	}
+	// Addresses are read up to the longer SMPP 5.0 limit, as some SMSCs
+	// exceed 21 octets for alphanumeric senders
+	for _, addr := range []struct {
+		name string
+		dst  *Address
+	}{{"source_addr", &d.Source}, {"destination_addr", &d.Dest}} {
+		if addr.dst.TON, err = r.octet(addr.name + "_ton"); err != nil {
+			return nil, err
+		}
+		if addr.dst.NPI, err = r.octet(addr.name + "_npi"); err != nil {
+			return nil, err
+		}
+		if addr.dst.Addr, err = r.cString(addr.name, maxESMEAddressLength); err != nil {
+			return nil, err
+		}
+	}
+
+	esmClass, err := r.octet("esm_class")
+	if err != nil {
+		return nil, err
+	}
+	d.EsmClass = ParseEsmClass(esmClass)
+	if d.ProtocolID, err = r.octet("protocol_id"); err != nil {
+		return nil, err
+	}
+	if d.Priority, err = r.octet("priority_flag"); err != nil {
+		return nil, err
+	}
+	// schedule_delivery_time and validity_period are unused in deliver_sm
+	if _, err = r.cString("schedule_delivery_time", maxTimeLength); err != nil {
+		return nil, err
+	}
+	if _, err = r.cString("validity_period", maxTimeLength); err != nil {
+		return nil, err
+	}
+	registered, err := r.octet("registered_delivery")
+	if err != nil {
+		return nil, err
+	}
+	d.RegisteredDelivery = ParseRegisteredDelivery(registered)
+	if _, err = r.octet("replace_if_present_flag"); err != nil {
+		return nil, err
+	}
+	if d.DataCoding, err = r.octet("data_coding"); err != nil {
+		return nil, err
+	}
+	if _, err = r.octet("sm_default_msg_id"); err != nil {
+		return nil, err
+	}
+	smLength, err := r.octet("sm_length")
+	if err != nil {
+		return nil, err
+	}
+	if d.Message, err = r.octets("short_message", int(smLength)); err != nil {
+		return nil, err
+	}
+
+	if d.TLVs, err = r.tlvs(); err != nil {
+		return nil, err
+	}
+	if err := d.decodeTLVs(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// decodeTLVs fills in the fields carried as optional parameters
+func (d *DeliverSM) decodeTLVs() error {
+	if v, ok := d.TLVs[TLV_MESSAGE_PAYLOAD]; ok && len(d.Message) == 0 {
+		d.Message = v
+		d.FromPayload = true
+	}
+	for _, port := range []struct {
+		tag uint16
+		dst **uint16
+	}{{TLV_SOURCE_PORT, &d.SourcePort}, {TLV_DESTINATION_PORT, &d.DestPort}} {
+		if v, ok := d.TLVs[port.tag]; ok {
+			if len(v) != 2 {
+				return fmt.Errorf("TLV 0x%04X has length %d", port.tag, len(v))
+			}
+			p := binary.BigEndian.Uint16(v)
+			*port.dst = &p
+		}
+	}
+	if v, ok := d.TLVs[TLV_MORE_MESSAGES]; ok && len(v) == 1 {
+		d.MoreMessagesToSend = v[0] != 0
+	}
+	if v, ok := d.TLVs[TLV_ITS_REPLY_TYPE]; ok && len(v) == 1 {
+		t := ITSReplyType(v[0])
+		d.ITSReplyType = &t
+	}
+	if v, ok := d.TLVs[TLV_ITS_SESSION_INFO]; ok {
+		info, err := parseITSSessionInfo(v)
+		if err != nil {
+			return err
+		}
+		d.ITSSessionInfo = &info
+	}
+	return nil
+}
+
+// IsSMEAck reports whether d is a delivery or manual acknowledgement from
+// the recipient's handset rather than a mobile-originated message, as
+// requested with RegisteredDelivery.SMEDeliveryAck and SMEManualAck
+func (d *DeliverSM) IsSMEAck() bool {
+	return d.EsmClass.Type == MessageTypeDeliveryAck || d.EsmClass.Type == MessageTypeManualAck
+}
+
+// inboundPDU is a deliver_sm waiting for the handler, with the connection
+// its response must go back on
+type inboundPDU struct {
+	pdu  *pdu
+	conn net.Conn
+}
+
+// OnDeliverSM sets the function called with every deliver_sm. Calls are
+// made one at a time, in arrival order, on a goroutine of their own unless
+// WithDeliverWorkers allows more, and the client sends deliver_sm_resp once
+// fn returns. Messages arriving
+// before a handler is set wait in the inbound buffer (see
+// WithInboundBuffer); when it is full the SMSC is asked to retry later.
+// Setting nil removes the handler: later deliver_sm are answered with
+// ESME_RX_T_APPN, a temporary error, so the SMSC retries them.
+func (c *Client) OnDeliverSM(fn func(*DeliverSM)) {
+	if fn == nil {
+		c.OnDeliverSMWithTLVs(nil)
+		return
+	}
+	c.OnDeliverSMWithTLVs(func(d *DeliverSM) map[uint16][]byte {
+		fn(d)
+		return nil
+	})
+}
+
+// OnDeliverSMWithTLVs is OnDeliverSM for the rare integrations that answer
+// with optional parameters: the TLVs fn returns, keyed by tag, are sent in
+// the deliver_sm_resp after its empty message_id, in tag order. Returning
+// nil sends the usual empty response.
+func (c *Client) OnDeliverSMWithTLVs(fn func(*DeliverSM) map[uint16][]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onDeliver = fn
+	if fn != nil {
+		c.startDeliverWorkers()
+	}
+}
+
+// startDeliverWorkers starts the goroutines taking deliver_sm off the
+// inbound queue, unless they already run. Must be called with c.mu held.
+func (c *Client) startDeliverWorkers() {
+	queue := c.inboundQueue()
+	c.deliverOnce.Do(func() {
+		for range c.deliverWorkers {
+			go c.deliverLoop(queue)
+		}
+	})
+}
+
+// OnSMEAck sets the function called with SME delivery and manual
+// acknowledgements (see IsSMEAck) instead of the OnDeliverSM handler, which
+// then only sees messages and receipts. Acks share the OnDeliverSM workers
+// and are only read once that handler is set; while fn is nil they go to
+// it like any other deliver_sm.
+func (c *Client) OnSMEAck(fn func(*DeliverSM)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onSMEAck = fn
+}
+
+// inboundQueue returns the queue of deliver_sm awaiting the handler. Must
+// be called with c.mu held.
+func (c *Client) inboundQueue() chan inboundPDU {
+	if c.inbound == nil {
+		c.inbound = make(chan inboundPDU, c.inboundBuffer)
+	}
+	return c.inbound
+}
+
+// queueDeliverSM hands a deliver_sm to the handler, refusing it when the
+// inbound buffer is full so the SMSC retries later. Must be called with
+// c.mu held.
+func (c *Client) queueDeliverSM(req *pdu) error {
+	select {
+	case c.inboundQueue() <- inboundPDU{pdu: req, conn: c.conn.conn}:
+		return nil
+	default:
+		c.counters.refusedInbound.Add(1)
+		return c.sendResponse(req.commandID, req.sequenceNumber, ESME_RMSGQFUL, nil)
+	}
+}
+
+// deliverLoop runs the handler on queued deliver_sm and acknowledges them.
+// WithDeliverWorkers sets how many run at once.
+func (c *Client) deliverLoop(queue chan inboundPDU) {
+	for in := range queue {
+		status := ESME_ROK
+		var tlvs map[uint16][]byte
+		d, err := parseDeliverSM(in.pdu.sequenceNumber, in.pdu.body)
+		if err != nil {
+			// Retrying cannot fix a malformed PDU, so reject it for good
+			c.logger.Warn("smpp: malformed deliver_sm", "sequence", in.pdu.sequenceNumber, "error", err)
+			status = ESME_RX_R_APPN
+		} else {
+			claimed := c.claimReceipt(d)
+			c.mu.Lock()
+			handler, ackHandler := c.onDeliver, c.onSMEAck
+			c.mu.Unlock()
+			switch {
+			case ackHandler != nil && d.IsSMEAck():
+				ackHandler(d)
+			case handler != nil:
+				tlvs = handler(d)
+			case claimed:
+			default:
+				status = ESME_RX_T_APPN
+			}
+		}
+		c.ackDeliverSM(in, status, tlvs)
+	}
+}
+
+// ackDeliverSM sends the deliver_sm_resp with tlvs, unless the connection
+// the deliver_sm came on has since been closed
+func (c *Client) ackDeliverSM(in inboundPDU, status uint32, tlvs map[uint16][]byte) {
+	resp := newPDU(DELIVER_SM_RESP, in.pdu.sequenceNumber)
+	resp.writeByte(0) // empty message_id
+	for _, tag := range slices.Sorted(maps.Keys(tlvs)) {
+		resp.writeTLV(tag, tlvs[tag])
+	}
+
+	c.mu.Lock()
+	if c.conn.conn != in.conn {
+		c.mu.Unlock()
+		return
+	}
+	err := c.sendResponse(DELIVER_SM, in.pdu.sequenceNumber, status, resp.body)
+	c.mu.Unlock()
+
+	if err != nil && isConnectionLost(err) {
+		c.connectionLost(err)
+	}
+}
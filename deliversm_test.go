@@ -0,0 +1,226 @@
+package smpp
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// deliverResps returns an SMSC handler recording deliver_sm_resp PDUs
+func deliverResps(resps chan *pdu) func(*smscConn, *pdu) bool {
+	return func(conn *smscConn, p *pdu) bool {
+		if p.commandID == DELIVER_SM_RESP {
+			resps <- p
+			return true
+		}
+		return false
+	}
+}
+
+func awaitResp(t *testing.T, resps chan *pdu) *pdu {
+	t.Helper()
+	select {
+	case p := <-resps:
+		return p
+	case <-time.After(2 * time.Second):
+		t.Fatal("no deliver_sm_resp")
+		return nil
+	}
+}
+
+func TestOnDeliverSMReplacingHandler(t *testing.T) {
+	resps := make(chan *pdu, 16)
+	smsc := newTestSMSC(t, deliverResps(resps))
+	c := smsc.client(WithBindMode(BindTransceiver))
+	connect(t, c)
+	conn := smsc.conn()
+
+	var running, overlapped atomic.Int32
+	var delivered atomic.Int32
+	handler := func(d *DeliverSM) {
+		if running.Add(1) > 1 {
+			overlapped.Add(1)
+		}
+		time.Sleep(5 * time.Millisecond)
+		delivered.Add(1)
+		running.Add(-1)
+	}
+
+	c.OnDeliverSM(handler)
+	conn.send(DELIVER_SM, ESME_ROK, 1, deliverSMBody("998901234567", "1234", 0, "first"))
+	if resp := awaitResp(t, resps); resp.commandStatus != ESME_ROK {
+		t.Fatalf("status = %#x, want ESME_ROK", resp.commandStatus)
+	}
+
+	// Without a handler the SMSC is told to retry rather than the client
+	// calling a nil func
+	c.OnDeliverSM(nil)
+	conn.send(DELIVER_SM, ESME_ROK, 2, deliverSMBody("998901234567", "1234", 0, "second"))
+	if resp := awaitResp(t, resps); resp.commandStatus != ESME_RX_T_APPN {
+		t.Fatalf("status = %#x, want ESME_RX_T_APPN", resp.commandStatus)
+	}
+
+	// A handler set again must not start a second loop
+	c.OnDeliverSM(handler)
+	for seq := uint32(3); seq < 13; seq++ {
+		conn.send(DELIVER_SM, ESME_ROK, seq, deliverSMBody("998901234567", "1234", 0, "more"))
+	}
+	for range 10 {
+		awaitResp(t, resps)
+	}
+	if delivered.Load() != 11 {
+		t.Fatalf("delivered %d messages, want 11", delivered.Load())
+	}
+	if overlapped.Load() != 0 {
+		t.Fatal("handler calls overlapped")
+	}
+}
+
+func TestDeliverSMResponseTLVs(t *testing.T) {
+	resps := make(chan *pdu, 2)
+	smsc := newTestSMSC(t, deliverResps(resps))
+	c := smsc.client(WithBindMode(BindTransceiver))
+	connect(t, c)
+	conn := smsc.conn()
+
+	c.OnDeliverSMWithTLVs(func(d *DeliverSM) map[uint16][]byte {
+		if string(d.Message) == "plain" {
+			return nil
+		}
+		return map[uint16][]byte{0x1402: []byte("b"), 0x1401: []byte("a")}
+	})
+
+	conn.send(DELIVER_SM, ESME_ROK, 1, deliverSMBody("998901234567", "1234", 0, "plain"))
+	if resp := awaitResp(t, resps); !bytes.Equal(resp.body, []byte{0}) {
+		t.Fatalf("body = % x, want the empty message_id alone", resp.body)
+	}
+
+	conn.send(DELIVER_SM, ESME_ROK, 2, deliverSMBody("998901234567", "1234", 0, "vendor"))
+	want := append([]byte{0}, tlv(0x1401, []byte("a"))...)
+	want = append(want, tlv(0x1402, []byte("b"))...)
+	if resp := awaitResp(t, resps); !bytes.Equal(resp.body, want) {
+		t.Fatalf("body = % x, want % x", resp.body, want)
+	}
+}
+
+func TestDeliverWorkersRunHandlerConcurrently(t *testing.T) {
+	resps := make(chan *pdu, 4)
+	smsc := newTestSMSC(t, deliverResps(resps))
+	c := smsc.client(WithBindMode(BindTransceiver), WithDeliverWorkers(4))
+	connect(t, c)
+	conn := smsc.conn()
+
+	var running atomic.Int32
+	release := make(chan struct{})
+	c.OnDeliverSM(func(d *DeliverSM) {
+		running.Add(1)
+		<-release
+	})
+	for seq := uint32(1); seq <= 4; seq++ {
+		conn.send(DELIVER_SM, ESME_ROK, seq, deliverSMBody("998901234567", "1234", 0, "slow"))
+	}
+
+	// All four are in the handler at once, none answered yet
+	waitFor(t, "four concurrent handler calls", func() bool { return running.Load() == 4 })
+	select {
+	case <-resps:
+		t.Fatal("deliver_sm answered before its handler returned")
+	default:
+	}
+	close(release)
+	for range 4 {
+		if resp := awaitResp(t, resps); resp.commandStatus != ESME_ROK {
+			t.Fatalf("status = %#x, want ESME_ROK", resp.commandStatus)
+		}
+	}
+}
+
+func TestSMEAcksGoToTheirOwnHandler(t *testing.T) {
+	resps := make(chan *pdu, 3)
+	smsc := newTestSMSC(t, deliverResps(resps))
+	c := smsc.client(WithBindMode(BindTransceiver))
+	connect(t, c)
+	conn := smsc.conn()
+
+	messages := make(chan string, 3)
+	acks := make(chan string, 3)
+	c.OnDeliverSM(func(d *DeliverSM) { messages <- string(d.Message) })
+	c.OnSMEAck(func(d *DeliverSM) { acks <- string(d.Message) })
+
+	conn.send(DELIVER_SM, ESME_ROK, 1, deliverSMBody("998901234567", "1234", byte(MessageTypeDefault), "mo"))
+	conn.send(DELIVER_SM, ESME_ROK, 2, deliverSMBody("998901234567", "1234", byte(MessageTypeDeliveryAck), "delivery ack"))
+	conn.send(DELIVER_SM, ESME_ROK, 3, deliverSMBody("998901234567", "1234", byte(MessageTypeManualAck), "manual ack"))
+	for range 3 {
+		awaitResp(t, resps)
+	}
+
+	if got := <-messages; got != "mo" || len(messages) != 0 {
+		t.Fatalf("OnDeliverSM got %q and %d more, want only the MO message", got, len(messages))
+	}
+	if a, b := <-acks, <-acks; a != "delivery ack" || b != "manual ack" {
+		t.Fatalf("OnSMEAck got %q, %q", a, b)
+	}
+}
+
+func TestDeliverSMDecodesMandatoryFields(t *testing.T) {
+	resps := make(chan *pdu, 1)
+	smsc := newTestSMSC(t, deliverResps(resps))
+	c := smsc.client(WithBindMode(BindTransceiver))
+	received := make(chan *DeliverSM, 1)
+	c.OnDeliverSM(func(d *DeliverSM) { received <- d })
+	connect(t, c)
+	conn := smsc.conn()
+
+	p := newPDU(DELIVER_SM, 0)
+	p.writeString("WAP") // service_type
+	p.writeByte(5)
+	p.writeByte(0)
+	p.writeString("Shop")
+	p.writeByte(1)
+	p.writeByte(1)
+	p.writeString("998901234567")
+	p.writeByte(0x80) // esm_class: reply path
+	p.writeByte(0x7F) // protocol_id
+	p.writeByte(2)    // priority_flag
+	p.writeString("")
+	p.writeString("")
+	p.writeByte(0x01) // registered_delivery
+	p.writeByte(0)
+	p.writeByte(0x08) // data_coding: UCS2
+	p.writeByte(0)
+	p.writeByte(4)
+	p.write([]byte{0x04, 0x1F, 0x04, 0x40}) // "Пр"
+	p.writeTLV(TLV_DESTINATION_PORT, []byte{0x0B, 0x84})
+	conn.send(DELIVER_SM, ESME_ROK, 42, p.body)
+
+	d := <-received
+	want := DeliverSM{
+		SequenceNumber:     42,
+		ServiceType:        "WAP",
+		Source:             Address{TON: 5, NPI: 0, Addr: "Shop"},
+		Dest:               Address{TON: 1, NPI: 1, Addr: "998901234567"},
+		EsmClass:           EsmClass{ReplyPath: true},
+		ProtocolID:         0x7F,
+		Priority:           2,
+		RegisteredDelivery: RegisteredDelivery{Receipt: ReceiptSuccessOrFailure},
+		DataCoding:         0x08,
+	}
+	if d.SequenceNumber != want.SequenceNumber || d.ServiceType != want.ServiceType ||
+		d.Source != want.Source || d.Dest != want.Dest || d.EsmClass != want.EsmClass ||
+		d.ProtocolID != want.ProtocolID || d.Priority != want.Priority ||
+		d.RegisteredDelivery != want.RegisteredDelivery || d.DataCoding != want.DataCoding {
+		t.Fatalf("deliver_sm = %+v, want %+v", d, want)
+	}
+	if text, _ := d.Text(); text != "Пр" {
+		t.Fatalf("text = %q", text)
+	}
+	if d.DestPort == nil || *d.DestPort != 2948 {
+		t.Fatal("destination_port not decoded")
+	}
+
+	resp := awaitResp(t, resps)
+	if resp.sequenceNumber != 42 || resp.commandStatus != ESME_ROK || !bytes.Equal(resp.body, []byte{0}) {
+		t.Fatalf("deliver_sm_resp seq %d status %#x body % x", resp.sequenceNumber, resp.commandStatus, resp.body)
+	}
+}
@@ -0,0 +1,56 @@
+package smpp
+
+// registered_delivery bit layout
+const (
+	registeredDeliveryReceiptMask  = 0x03 // bits 1-0: SMSC delivery receipt
+	registeredDeliverySMEDelivery  = 0x04 // bit 2: SME delivery acknowledgement
+	registeredDeliverySMEManual    = 0x08 // bit 3: SME manual/user acknowledgement
+	registeredDeliveryIntermediate = 0x10 // bit 4: intermediate notification
+)
+
+// ReceiptRequest selects when the SMSC returns a delivery receipt
+type ReceiptRequest byte
+
+const (
+	ReceiptNone             ReceiptRequest = 0x00 // no delivery receipt
+	ReceiptSuccessOrFailure ReceiptRequest = 0x01 // receipt on final success or failure
+	ReceiptFailure          ReceiptRequest = 0x02 // receipt on delivery failure only
+)
+
+// RegisteredDelivery describes the registered_delivery field of a submit
+type RegisteredDelivery struct {
+	Receipt        ReceiptRequest
+	SMEDeliveryAck bool
+	SMEManualAck   bool
+
+	// Intermediate asks for notifications on the way to final delivery,
+	// useful with a long validity period. They arrive as deliver_sm with
+	// message type MessageTypeIntermediate in esm_class.
+	Intermediate bool
+}
+
+// Encode packs r into a registered_delivery byte
+func (r RegisteredDelivery) Encode() byte {
+	b := byte(r.Receipt) & registeredDeliveryReceiptMask
+	if r.SMEDeliveryAck {
+		b |= registeredDeliverySMEDelivery
+	}
+	if r.SMEManualAck {
+		b |= registeredDeliverySMEManual
+	}
+	if r.Intermediate {
+		b |= registeredDeliveryIntermediate
+	}
+	return b
+}
+
+// ParseRegisteredDelivery unpacks a registered_delivery byte. Reserved bits
+// 5-7 are ignored.
+func ParseRegisteredDelivery(b byte) RegisteredDelivery {
+	return RegisteredDelivery{
+		Receipt:        ReceiptRequest(b & registeredDeliveryReceiptMask),
+		SMEDeliveryAck: b&registeredDeliverySMEDelivery != 0,
+		SMEManualAck:   b&registeredDeliverySMEManual != 0,
+		Intermediate:   b&registeredDeliveryIntermediate != 0,
+	}
+}
@@ -0,0 +1,48 @@
+package smpp
+
+import "testing"
+
+// The registered_delivery values defined in SMPP 3.4 section 5.2.17
+func TestRegisteredDeliveryStandardBits(t *testing.T) {
+	cases := []struct {
+		b byte
+		r RegisteredDelivery
+	}{
+		{0x00, RegisteredDelivery{}},
+		{0x01, RegisteredDelivery{Receipt: ReceiptSuccessOrFailure}},
+		{0x02, RegisteredDelivery{Receipt: ReceiptFailure}},
+		{0x04, RegisteredDelivery{SMEDeliveryAck: true}},
+		{0x08, RegisteredDelivery{SMEManualAck: true}},
+		{0x0C, RegisteredDelivery{SMEDeliveryAck: true, SMEManualAck: true}},
+		{0x10, RegisteredDelivery{Intermediate: true}},
+		{0x1D, RegisteredDelivery{Receipt: ReceiptSuccessOrFailure, SMEManualAck: true, SMEDeliveryAck: true, Intermediate: true}},
+	}
+	for _, tc := range cases {
+		if got := tc.r.Encode(); got != tc.b {
+			t.Errorf("%+v encodes as %#02x, want %#02x", tc.r, got, tc.b)
+		}
+		if got := ParseRegisteredDelivery(tc.b); got != tc.r {
+			t.Errorf("%#02x parses as %+v, want %+v", tc.b, got, tc.r)
+		}
+	}
+
+	// Reserved bits 5-7 are dropped
+	if got := ParseRegisteredDelivery(0xE1); got != (RegisteredDelivery{Receipt: ReceiptSuccessOrFailure}) {
+		t.Errorf("0xE1 parses as %+v", got)
+	}
+}
+
+func TestSendSMSRegisteredDelivery(t *testing.T) {
+	submits := make(chan *DeliverSM, 1)
+	smsc := newTestSMSC(t, recordSubmits(submits))
+	c := smsc.client()
+	connect(t, c)
+
+	want := RegisteredDelivery{Receipt: ReceiptFailure, SMEDeliveryAck: true}
+	if _, err := c.SendSMS(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi"), RegisteredDelivery: want}); err != nil {
+		t.Fatal(err)
+	}
+	if got := (<-submits).RegisteredDelivery; got != want {
+		t.Fatalf("registered_delivery = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package smpp
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrDestinationThrottled is returned when a submit would exceed the
+// per-destination limit set with WithDestinationLimit
+var ErrDestinationThrottled = errors.New("smpp: per-destination limit exceeded")
+
+// maxTrackedDestinations caps the destinations a destLimiter remembers;
+// the least recently used are forgotten first
+const maxTrackedDestinations = 10000
+
+// destLimiter allows at most limit submits per destination in each fixed
+// window
+type destLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows *lru[string, *destWindow]
+}
+
+type destWindow struct {
+	start time.Time
+	count int
+}
+
+func newDestLimiter(limit int, window time.Duration) *destLimiter {
+	return &destLimiter{
+		limit:   limit,
+		window:  window,
+		windows: newLRU[string, *destWindow](maxTrackedDestinations),
+	}
+}
+
+// allow counts a submit to dest at now and reports whether it is within
+// the limit
+func (l *destLimiter) allow(dest string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows.get(dest)
+	if !ok || now.Sub(w.start) >= l.window {
+		l.windows.put(dest, &destWindow{start: now, count: 1})
+		return true
+	}
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	return true
+}
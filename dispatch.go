@@ -0,0 +1,168 @@
+package smpp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// dispatcher hands the responses read by the read loop to the requests
+// waiting for them, keyed by sequence number
+type dispatcher struct {
+	mu      sync.Mutex
+	pending map[uint32]chan *pdu
+	done    chan struct{} // closed when the read loop ends
+	err     error         // why the read loop ended, set before done closes
+}
+
+func newDispatcher() *dispatcher {
+	return &dispatcher{
+		pending: make(map[uint32]chan *pdu),
+		done:    make(chan struct{}),
+	}
+}
+
+// register returns the channel that receives the response to seq. It
+// refuses a seq that is still awaiting a response rather than take over
+// that response.
+func (d *dispatcher) register(seq uint32) (chan *pdu, error) {
+	ch := make(chan *pdu, 1)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.pending[seq]; ok {
+		return nil, fmt.Errorf("sequence number %d is still awaiting a response", seq)
+	}
+	if d.pending != nil {
+		d.pending[seq] = ch
+	}
+	return ch, nil
+}
+
+// isPending reports whether seq is awaiting a response
+func (d *dispatcher) isPending(seq uint32) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.pending[seq]
+	return ok
+}
+
+// cancel forgets seq after its request failed or timed out
+func (d *dispatcher) cancel(seq uint32) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.pending, seq)
+}
+
+// deliver hands resp to the request waiting for it, reporting false if
+// there is none
+func (d *dispatcher) deliver(resp *pdu) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ch, ok := d.pending[resp.sequenceNumber]
+	if !ok {
+		return false
+	}
+	delete(d.pending, resp.sequenceNumber)
+	ch <- resp
+	return true
+}
+
+// stop ends dispatching, failing the waiting requests with err
+func (d *dispatcher) stop(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.err = err
+	d.pending = nil
+	close(d.done)
+}
+
+// startReadLoop starts reading the connection in the background, for
+// sessions where the SMSC sends PDUs on its own and for pipelining.
+// Requests then get their responses from the read loop instead of reading
+// themselves. It does nothing if a loop already runs.
+func (c *Client) startReadLoop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dispatch.Load() != nil || c.conn.conn == nil {
+		return
+	}
+	d := newDispatcher()
+	c.dispatch.Store(d)
+	go c.readLoop(d, c.conn.conn)
+}
+
+// readLoop runs until conn fails or is closed, then treats the connection
+// as lost unless the client had already closed or replaced it
+func (c *Client) readLoop(d *dispatcher, conn net.Conn) {
+	err := c.readPDUs(d, conn)
+	d.stop(err)
+	c.dispatch.CompareAndSwap(d, nil)
+
+	c.mu.Lock()
+	current := c.conn.conn == conn
+	c.mu.Unlock()
+	if current {
+		c.connectionLost(err)
+	}
+}
+
+// readPDUs dispatches responses and answers requests until a read or a
+// reply fails. Reads wait indefinitely, as an idle session is normal,
+// unless WithIdleTimeout set a deadline, which restarts with each PDU.
+func (c *Client) readPDUs(d *dispatcher, conn net.Conn) error {
+	for {
+		var deadline time.Time
+		if c.idleTimeout > 0 {
+			deadline = time.Now().Add(c.idleTimeout)
+		}
+		p, err := c.conn.readPDUFrom(conn, deadline)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return fmt.Errorf("no PDU received for %s: %w", c.idleTimeout, err)
+		}
+		if err != nil {
+			return err
+		}
+		if p.isResponse() {
+			if !d.deliver(p) {
+				c.orphanedResponse(p)
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		err = c.handleRequest(p)
+		c.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// dispatchedRoundTrip writes pdu and waits for the read loop to deliver
+// its response, up to timeout
+func (c *Client) dispatchedRoundTrip(d *dispatcher, pdu *pdu, timeout time.Duration) (*pdu, error) {
+	ch, err := d.register(pdu.sequenceNumber)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	err = c.write(pdu)
+	c.mu.Unlock()
+	if err != nil {
+		d.cancel(pdu.sequenceNumber)
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-d.done:
+		return nil, d.err
+	case <-c.clock.After(timeout):
+		d.cancel(pdu.sequenceNumber)
+		return nil, fmt.Errorf("no %s received: %w", commandName(pdu.commandID|responseBit), os.ErrDeadlineExceeded)
+	}
+}
@@ -0,0 +1,83 @@
+package smpp
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNextSequenceSkipsPendingAcrossWraparound(t *testing.T) {
+	seq := &counterSequence{}
+	seq.last.Store(maxSequence - 2)
+	c := NewClient("127.0.0.1", 2775, "user", "pass", WithSequenceGenerator(seq))
+
+	d := newDispatcher()
+	c.dispatch.Store(d)
+	for _, pending := range []uint32{maxSequence, 1} {
+		if _, err := d.register(pending); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []uint32{maxSequence - 1, 2, 3} {
+		got := c.nextSequence()
+		if got != want {
+			t.Fatalf("nextSequence = %d, want %d", got, want)
+		}
+		if _, err := d.register(got); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRegisterRefusesPendingSequence(t *testing.T) {
+	d := newDispatcher()
+	first, err := d.register(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.register(7); err == nil {
+		t.Fatal("registered sequence 7 twice")
+	}
+
+	// The first request still gets its response
+	resp := newPDU(SUBMIT_SM_RESP, 7)
+	if !d.deliver(resp) {
+		t.Fatal("response not delivered")
+	}
+	if got := <-first; got != resp {
+		t.Fatal("response went elsewhere")
+	}
+	if _, err := d.register(7); err != nil {
+		t.Fatalf("register after the response: %v", err)
+	}
+}
+
+func TestIdleTimeoutRestartsOnEachPDU(t *testing.T) {
+	smsc := newTestSMSC(t, nil)
+	lost := make(chan error, 1)
+	c := smsc.client(WithBindMode(BindTransceiver), WithIdleTimeout(200*time.Millisecond))
+	c.OnConnectionLost(func(err error) { lost <- err })
+	connect(t, c)
+	conn := smsc.conn()
+
+	// Traffic every 50ms keeps the session up well past the idle timeout
+	for seq := uint32(1); seq <= 10; seq++ {
+		conn.send(ENQUIRE_LINK, ESME_ROK, seq, nil)
+		time.Sleep(50 * time.Millisecond)
+	}
+	if c.State() != StateBound {
+		t.Fatalf("state = %s after steady traffic, want bound", c.State())
+	}
+
+	// Silence for the full interval drops it
+	select {
+	case err := <-lost:
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatalf("connection lost with %v, want a deadline error", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle connection was not dropped")
+	}
+}
@@ -0,0 +1,175 @@
+package smpp
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShuttingDown is returned for submits started after Shutdown or
+// DisconnectContext began, and for queued parts dropped at its deadline
+var ErrShuttingDown = errors.New("client is shutting down")
+
+// ShutdownSummary reports what became of submits during a shutdown
+type ShutdownSummary struct {
+	Completed int  // submits answered by the SMSC while draining
+	Abandoned int  // submits in flight that were never answered: dropped unsent at the deadline or cut off by the disconnect
+	Rejected  int  // submits refused with ErrShuttingDown
+	Drained   bool // everything in flight was answered before the deadline
+}
+
+// drain tracks submit operations in flight so a shutdown can wait for
+// them. An operation is a SendSMS, a SendLongSMS or a pipelined batch.
+type drain struct {
+	mu        sync.Mutex
+	closing   bool
+	shutdowns int // Shutdown calls sharing the current shutdown
+	inflight  int
+	idle      chan struct{}   // closed when inflight drops to zero while closing
+	abort     chan struct{}   // closed at the shutdown deadline
+	summary   ShutdownSummary // shared by every Shutdown joining this one
+}
+
+// enter registers an operation, refusing it once a shutdown has begun
+func (d *drain) enter() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closing {
+		d.summary.Rejected++
+		return false
+	}
+	d.inflight++
+	return true
+}
+
+// leave ends an operation registered with enter
+func (d *drain) leave() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inflight--
+	if d.closing && d.inflight == 0 {
+		close(d.idle)
+	}
+}
+
+// record counts a submit finished while closing, answered or not
+func (d *drain) record(answered bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case !d.closing:
+	case answered:
+		d.summary.Completed++
+	default:
+		d.summary.Abandoned++
+		d.summary.Drained = false
+	}
+}
+
+// abandon counts n submits dropped unsent while closing
+func (d *drain) abandon(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closing && n > 0 {
+		d.summary.Abandoned += n
+		d.summary.Drained = false
+	}
+}
+
+// aborted reports whether the shutdown deadline has passed, after which
+// operations stop sending
+func (d *drain) aborted() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.abort == nil {
+		return false
+	}
+	select {
+	case <-d.abort:
+		return true
+	default:
+		return false
+	}
+}
+
+// start begins a shutdown, or joins the one in progress, and returns a
+// channel closed once no operation is in flight
+func (d *drain) start() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.shutdowns++
+	if d.closing {
+		return d.idle
+	}
+	d.closing = true
+	d.summary.Drained = true
+	d.idle = make(chan struct{})
+	d.abort = make(chan struct{})
+	if d.inflight == 0 {
+		close(d.idle)
+	}
+	return d.idle
+}
+
+// stop makes operations still in flight give up sending
+func (d *drain) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.inflight > 0 {
+		d.summary.Drained = false
+	}
+	select {
+	case <-d.abort: // another Shutdown reached its deadline first
+	default:
+		close(d.abort)
+	}
+}
+
+// finish leaves the shutdown and returns its summary. The last caller to
+// leave ends it, accepting operations again.
+func (d *drain) finish() ShutdownSummary {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.summary
+	d.shutdowns--
+	if d.shutdowns == 0 {
+		d.closing = false
+		d.idle, d.abort = nil, nil
+		d.summary = ShutdownSummary{}
+	}
+	return s
+}
+
+// Shutdown stops accepting submits, waits within ctx for those in flight,
+// including the rest of a SendLongSMS or batch, to be sent and answered,
+// then unbinds and closes the connection. At the deadline, parts not yet
+// sent fail with ErrShuttingDown and the connection is closed at once. The
+// summary reports what was and wasn't completed; the error is that of
+// the unbind, as for DisconnectContext.
+//
+// With WithReceiptGrace, a session that receives deliver_sm stays bound
+// for the grace period after draining, still refusing submits, so receipts
+// for the messages just sent can arrive; ctx bounds that wait too.
+func (c *Client) Shutdown(ctx context.Context) (ShutdownSummary, error) {
+	idle := c.drain.start()
+	drained := true
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		drained = false
+		c.drain.stop()
+	}
+
+	if drained && c.receiptGrace > 0 && c.bindMode.readsContinuously() {
+		select {
+		case <-c.clock.After(c.receiptGrace):
+		case <-ctx.Done():
+		}
+	}
+
+	err := c.disconnect(ctx)
+
+	// The closed socket ends any operation still reading a response
+	<-idle
+	return c.drain.finish(), err
+}
@@ -0,0 +1,186 @@
+package smpp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowSubmits returns an SMSC handler that answers submit_sm after delay,
+// signalling received as each arrives
+func slowSubmits(delay time.Duration, received chan struct{}) func(*smscConn, *pdu) bool {
+	return func(conn *smscConn, p *pdu) bool {
+		if p.commandID != SUBMIT_SM {
+			return false
+		}
+		received <- struct{}{}
+		go func() {
+			time.Sleep(delay)
+			defaultReply(conn, p)
+		}()
+		return true
+	}
+}
+
+// concurrently runs fn n times in parallel and waits, failing the test if
+// that takes longer than two seconds
+func concurrently(t *testing.T, n int, fn func()) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("calls did not return")
+	}
+}
+
+func TestConcurrentShutdownsPastDeadline(t *testing.T) {
+	received := make(chan struct{}, 1)
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID != SUBMIT_SM {
+			return false
+		}
+		received <- struct{}{}
+		return true // never answered
+	})
+	c := smsc.client()
+	connect(t, c)
+
+	go c.SendSMS(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+	<-received
+
+	// Both shutdowns join while the submit is in flight, then reach
+	// their deadline together
+	ctx, cancel := context.WithCancel(context.Background())
+	summaries := make(chan ShutdownSummary, 2)
+	for range 2 {
+		go func() {
+			summary, _ := c.Shutdown(ctx)
+			summaries <- summary
+		}()
+	}
+	waitFor(t, "both shutdowns to join", func() bool {
+		c.drain.mu.Lock()
+		defer c.drain.mu.Unlock()
+		return c.drain.shutdowns == 2
+	})
+	cancel()
+
+	for range 2 {
+		select {
+		case summary := <-summaries:
+			if summary.Drained || summary.Abandoned != 1 {
+				t.Errorf("summary = %+v, want one abandoned submit and not drained", summary)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("shutdown did not return")
+		}
+	}
+	if c.State() != StateDisconnected {
+		t.Fatalf("state = %s, want disconnected", c.State())
+	}
+}
+
+func TestConcurrentDisconnects(t *testing.T) {
+	received := make(chan struct{}, 1)
+	smsc := newTestSMSC(t, slowSubmits(100*time.Millisecond, received))
+	c := smsc.client()
+	connect(t, c)
+
+	sent := make(chan error, 1)
+	go func() {
+		_, err := c.SendSMS(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+		sent <- err
+	}()
+	<-received
+
+	concurrently(t, 2, func() { c.Disconnect() })
+	if err := <-sent; err != nil {
+		t.Fatalf("in-flight submit failed: %v", err)
+	}
+
+	// The next shutdown starts afresh
+	if err := c.Connect(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendSMS(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")}); err != nil {
+		t.Fatalf("submit after reconnecting: %v", err)
+	}
+}
+
+func TestShutdownReceiptGrace(t *testing.T) {
+	resps := make(chan *pdu, 1)
+	smsc := newTestSMSC(t, deliverResps(resps))
+	c := smsc.client(WithBindMode(BindTransceiver), WithReceiptGrace(300*time.Millisecond))
+	connect(t, c)
+	conn := smsc.conn()
+
+	receipts := make(chan *DeliverSM, 1)
+	c.OnDeliverSM(func(d *DeliverSM) { receipts <- d })
+	msg := &SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")}
+	id, err := c.SendSMS(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Shutdown(context.Background())
+	}()
+	waitFor(t, "submits to be refused", func() bool {
+		_, err := c.SendSMS(msg)
+		return errors.Is(err, ErrShuttingDown)
+	})
+
+	// The receipt still gets through during the grace period
+	conn.send(DELIVER_SM, ESME_ROK, 1, receiptFor(id))
+	if resp := awaitResp(t, resps); resp.commandStatus != ESME_ROK {
+		t.Fatalf("status = %#x, want ESME_ROK", resp.commandStatus)
+	}
+	if d := <-receipts; !d.IsDeliveryReceipt() {
+		t.Fatal("handler got something other than the receipt")
+	}
+
+	<-done
+	if c.State() != StateDisconnected {
+		t.Fatalf("state = %s after the grace period, want disconnected", c.State())
+	}
+}
+
+func TestShutdownJoiningAfterIdleSharesSummary(t *testing.T) {
+	var d drain
+	if !d.enter() {
+		t.Fatal("operation refused before any shutdown")
+	}
+	d.start()
+	d.stop() // the first shutdown's deadline passes with the submit in flight
+	d.record(false)
+	d.leave()
+
+	// A second shutdown joins once the drain is idle
+	select {
+	case <-d.start():
+	default:
+		t.Fatal("drain not idle")
+	}
+	for range 2 {
+		if s := d.finish(); s.Drained || s.Abandoned != 1 {
+			t.Fatalf("summary = %+v, want one abandoned submit and not drained", s)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package smpp
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// commandNames maps command IDs to their specification names
+var commandNames = func() map[uint32]string {
+	names := make(map[uint32]string, len(knownCommands))
+	for _, cmd := range knownCommands {
+		names[cmd.ID] = cmd.Name
+	}
+	return names
+}()
+
+// commandName returns the specification name of a command ID
+func commandName(id uint32) string {
+	if name, ok := commandNames[id]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// fieldKind says how a mandatory body field is encoded
+type fieldKind int
+
+const (
+	fieldCString      fieldKind = iota
+	fieldOctet                  // one-octet integer
+	fieldShortMessage           // octets counted by the preceding sm_length
+)
+
+// bodyField is a mandatory field of a PDU body
+type bodyField struct {
+	name string
+	kind fieldKind
+}
+
+// Mandatory field sequences shared by several commands
+var (
+	sourceFields = []bodyField{
+		{"source_addr_ton", fieldOctet}, {"source_addr_npi", fieldOctet}, {"source_addr", fieldCString},
+	}
+	destFields = []bodyField{
+		{"dest_addr_ton", fieldOctet}, {"dest_addr_npi", fieldOctet}, {"destination_addr", fieldCString},
+	}
+	shortMessageFields = []bodyField{
+		{"sm_length", fieldOctet}, {"short_message", fieldShortMessage},
+	}
+	bindFields = []bodyField{
+		{"system_id", fieldCString}, {"password", fieldCString}, {"system_type", fieldCString},
+		{"interface_version", fieldOctet}, {"addr_ton", fieldOctet}, {"addr_npi", fieldOctet},
+		{"address_range", fieldCString},
+	}
+	messageIDFields = []bodyField{{"message_id", fieldCString}}
+	systemIDFields  = []bodyField{{"system_id", fieldCString}}
+)
+
+// fields joins field sequences into one body layout
+func fields(seqs ...[]bodyField) []bodyField {
+	var all []bodyField
+	for _, seq := range seqs {
+		all = append(all, seq...)
+	}
+	return all
+}
+
+// messageFields is the body layout of submit_sm and deliver_sm
+var messageFields = fields(
+	[]bodyField{{"service_type", fieldCString}}, sourceFields, destFields,
+	[]bodyField{
+		{"esm_class", fieldOctet}, {"protocol_id", fieldOctet}, {"priority_flag", fieldOctet},
+		{"schedule_delivery_time", fieldCString}, {"validity_period", fieldCString},
+		{"registered_delivery", fieldOctet}, {"replace_if_present_flag", fieldOctet},
+		{"data_coding", fieldOctet}, {"sm_default_msg_id", fieldOctet},
+	},
+	shortMessageFields,
+)
+
+// bodyLayouts lists the mandatory fields of each command with a body;
+// whatever follows them is read as TLVs
+var bodyLayouts = map[uint32][]bodyField{
+	BIND_RECEIVER:         bindFields,
+	BIND_TRANSMITTER:      bindFields,
+	BIND_TRANSCEIVER:      bindFields,
+	BIND_RECEIVER_RESP:    systemIDFields,
+	BIND_TRANSMITTER_RESP: systemIDFields,
+	BIND_TRANSCEIVER_RESP: systemIDFields,
+	SUBMIT_SM:             messageFields,
+	DELIVER_SM:            messageFields,
+	SUBMIT_SM_RESP:        messageIDFields,
+	DELIVER_SM_RESP:       messageIDFields,
+	DATA_SM_RESP:          messageIDFields,
+	DATA_SM: fields(
+		[]bodyField{{"service_type", fieldCString}}, sourceFields, destFields,
+		[]bodyField{{"esm_class", fieldOctet}, {"registered_delivery", fieldOctet}, {"data_coding", fieldOctet}},
+	),
+	QUERY_SM: fields(messageIDFields, sourceFields),
+	QUERY_SM_RESP: fields(messageIDFields, []bodyField{
+		{"final_date", fieldCString}, {"message_state", fieldOctet}, {"error_code", fieldOctet},
+	}),
+	REPLACE_SM: fields(messageIDFields, sourceFields, []bodyField{
+		{"schedule_delivery_time", fieldCString}, {"validity_period", fieldCString},
+		{"registered_delivery", fieldOctet}, {"sm_default_msg_id", fieldOctet},
+	}, shortMessageFields),
+	ALERT_NOTIFICATION: fields(sourceFields, []bodyField{
+		{"esme_addr_ton", fieldOctet}, {"esme_addr_npi", fieldOctet}, {"esme_addr", fieldCString},
+	}),
+}
+
+// tlvNames maps TLV tags to their specification names
+var tlvNames = func() map[uint16]string {
+	names := make(map[uint16]string, len(knownTLVs))
+	for _, tlv := range knownTLVs {
+		names[tlv.Tag] = tlv.Name
+	}
+	return names
+}()
+
+// FormatPDU renders a serialized PDU as stable, human-readable text: the
+// four header fields, then each mandatory field by name and each TLV in
+// wire order. Octets it cannot attribute to a field, such as the body of
+// a vendor command or a truncated field, follow as a hex dump. It is meant
+// for golden-file comparisons in tests, where a regression shows up as a
+// readable diff.
+func FormatPDU(raw []byte) (string, error) {
+	p, err := decodePDU(raw)
+	if err != nil {
+		return "", err
+	}
+	return p.String(), nil
+}
+
+// String renders the PDU in the FormatPDU layout
+func (p *pdu) String() string {
+	var b strings.Builder
+	line := func(name, format string, args ...any) {
+		v := fmt.Sprintf(format, args...)
+		b.WriteString(strings.TrimRight(fmt.Sprintf("%-25s%s", name+":", v), " ") + "\n")
+	}
+	line("command_length", "%d", pduHeaderLength+len(p.body))
+	line("command_id", "0x%08X (%s)", p.commandID, commandName(p.commandID))
+	line("command_status", "0x%08X", p.commandStatus)
+	line("sequence_number", "%d", p.sequenceNumber)
+
+	r := &bodyReader{b: p.body}
+	layout, ok := bodyLayouts[p.commandID]
+	if !ok && len(p.body) > 0 {
+		b.WriteString("body:\n")
+		b.WriteString(hex.Dump(p.body))
+		return b.String()
+	}
+
+	var smLength byte
+decode:
+	for _, f := range layout {
+		if r.off == len(r.b) {
+			break // error responses may leave out the body
+		}
+		switch f.kind {
+		case fieldCString:
+			v, err := r.cString(f.name, len(r.b))
+			if err != nil {
+				break decode
+			}
+			line(f.name, "%q", v)
+		case fieldOctet:
+			v, _ := r.octet(f.name)
+			line(f.name, "0x%02X", v)
+			smLength = v
+		case fieldShortMessage:
+			v, err := r.octets(f.name, int(smLength))
+			if err != nil {
+				break decode
+			}
+			line(f.name, "% X", v)
+		}
+	}
+
+	for len(r.b)-r.off >= 4 {
+		tag := binary.BigEndian.Uint16(r.b[r.off:])
+		length := int(binary.BigEndian.Uint16(r.b[r.off+2:]))
+		if length > len(r.b)-r.off-4 {
+			break
+		}
+		name, ok := tlvNames[tag]
+		if !ok {
+			name = "unknown"
+		}
+		line(fmt.Sprintf("tlv 0x%04X", tag), "(%s) % X", name, r.b[r.off+4:r.off+4+length])
+		r.off += 4 + length
+	}
+
+	if r.off < len(r.b) {
+		b.WriteString("undecoded:\n")
+		b.WriteString(hex.Dump(r.b[r.off:]))
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package smpp
+
+import "testing"
+
+// capturePDUs returns an SMSC handler that sends each PDU with commandID
+// to captured before the default reply
+func capturePDUs(commandID uint32, captured chan *pdu) func(*smscConn, *pdu) bool {
+	return func(conn *smscConn, p *pdu) bool {
+		if p.commandID == commandID {
+			captured <- p
+		}
+		return false
+	}
+}
+
+// checkFormat compares the FormatPDU rendering of p with want
+func checkFormat(t *testing.T, p *pdu, want string) {
+	t.Helper()
+	got, err := FormatPDU(p.encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("FormatPDU:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatPDUSubmitSM(t *testing.T) {
+	submits := make(chan *pdu, 1)
+	smsc := newTestSMSC(t, capturePDUs(SUBMIT_SM, submits))
+	c := smsc.client()
+	connect(t, c)
+
+	msg := &SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi"), MoreMessagesToSend: true}
+	if _, err := c.SendSMS(msg); err != nil {
+		t.Fatal(err)
+	}
+	checkFormat(t, <-submits, `command_length:          56
+command_id:              0x00000004 (submit_sm)
+command_status:          0x00000000
+sequence_number:         2
+service_type:            ""
+source_addr_ton:         0x00
+source_addr_npi:         0x00
+source_addr:             "Shop"
+dest_addr_ton:           0x01
+dest_addr_npi:           0x01
+destination_addr:        "998901234567"
+esm_class:               0x00
+protocol_id:             0x00
+priority_flag:           0x00
+schedule_delivery_time:  ""
+validity_period:         ""
+registered_delivery:     0x00
+replace_if_present_flag: 0x00
+data_coding:             0x00
+sm_default_msg_id:       0x00
+sm_length:               0x02
+short_message:           68 69
+tlv 0x0426:              (more_messages_to_send) 01
+`)
+}
+
+func TestFormatPDUWithoutLayout(t *testing.T) {
+	// An error response without its body
+	resp := newPDU(SUBMIT_SM_RESP, 3)
+	resp.commandStatus = ESME_RTHROTTLED
+	checkFormat(t, resp, `command_length:          16
+command_id:              0x80000004 (submit_sm_resp)
+command_status:          0x00000058
+sequence_number:         3
+`)
+
+	// A vendor command, whose body is opaque
+	vendor := newPDU(vendorCommandFirst, 4)
+	vendor.write([]byte{0x01, 0x02, 0x03})
+	checkFormat(t, vendor, `command_length:          19
+command_id:              0x00010000 (unknown)
+command_status:          0x00000000
+sequence_number:         4
+body:
+00000000  01 02 03                                          |...|
+`)
+}
@@ -0,0 +1,80 @@
+package smpp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// callingCode is the country calling code and national trunk prefix of a region
+type callingCode struct {
+	code  string
+	trunk string
+}
+
+// regions maps ISO 3166-1 alpha-2 codes to their calling codes
+var regions = map[string]callingCode{
+	"AE": {"971", "0"}, "AF": {"93", "0"}, "AM": {"374", "0"}, "AR": {"54", "0"},
+	"AT": {"43", "0"}, "AU": {"61", "0"}, "AZ": {"994", "0"}, "BD": {"880", "0"},
+	"BE": {"32", "0"}, "BG": {"359", "0"}, "BR": {"55", "0"}, "BY": {"375", "8"},
+	"CA": {"1", "1"}, "CH": {"41", "0"}, "CN": {"86", "0"}, "CY": {"357", ""},
+	"CZ": {"420", ""}, "DE": {"49", "0"}, "DK": {"45", ""}, "EE": {"372", ""},
+	"EG": {"20", "0"}, "ES": {"34", ""}, "FI": {"358", "0"}, "FR": {"33", "0"},
+	"GB": {"44", "0"}, "GE": {"995", "0"}, "GR": {"30", ""}, "HK": {"852", ""},
+	"HR": {"385", "0"}, "HU": {"36", "06"}, "ID": {"62", "0"}, "IE": {"353", "0"},
+	"IL": {"972", "0"}, "IN": {"91", "0"}, "IR": {"98", "0"}, "IT": {"39", ""},
+	"JP": {"81", "0"}, "KG": {"996", "0"}, "KR": {"82", "0"}, "KZ": {"7", "8"},
+	"LT": {"370", "8"}, "LV": {"371", ""}, "MD": {"373", "0"}, "MN": {"976", "0"},
+	"MX": {"52", ""}, "MY": {"60", "0"}, "NG": {"234", "0"}, "NL": {"31", "0"},
+	"NO": {"47", ""}, "NZ": {"64", "0"}, "PH": {"63", "0"}, "PK": {"92", "0"},
+	"PL": {"48", ""}, "PT": {"351", ""}, "QA": {"974", ""}, "RO": {"40", "0"},
+	"RS": {"381", "0"}, "RU": {"7", "8"}, "SA": {"966", "0"}, "SE": {"46", "0"},
+	"SG": {"65", ""}, "SK": {"421", "0"}, "TH": {"66", "0"}, "TJ": {"992", "8"},
+	"TM": {"993", "8"}, "TR": {"90", "0"}, "UA": {"380", "0"}, "US": {"1", "1"},
+	"UZ": {"998", "8"}, "VN": {"84", "0"}, "ZA": {"27", "0"},
+}
+
+// NormalizeE164 canonicalizes a phone number to international format: the
+// country code followed by the subscriber number, digits only, as SMPP
+// expects with TON/NPI 1/1 (no leading '+').
+//
+// Numbers starting with '+' or the "00" international prefix are taken as
+// international. Other numbers are national numbers of defaultRegion (an
+// ISO 3166-1 alpha-2 code): the region's trunk prefix is dropped and its
+// country code prepended, unless the digits already start with the country
+// code and are at least 11 long. Spaces, dashes, dots and parentheses are
+// ignored.
+func NormalizeE164(number, defaultRegion string) (string, error) {
+	var digits strings.Builder
+	international := false
+	for i, r := range strings.TrimSpace(number) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			international = true
+		case r == ' ', r == '-', r == '.', r == '(', r == ')':
+		default:
+			return "", fmt.Errorf("invalid phone number %q: unexpected character %q", number, r)
+		}
+	}
+
+	n := digits.String()
+	switch {
+	case international:
+	case strings.HasPrefix(n, "00"):
+		n = n[2:]
+	default:
+		region, ok := regions[strings.ToUpper(defaultRegion)]
+		if !ok {
+			return "", fmt.Errorf("invalid phone number %q: unknown region %q", number, defaultRegion)
+		}
+		if !strings.HasPrefix(n, region.code) || len(n) < 11 {
+			n = region.code + strings.TrimPrefix(n, region.trunk)
+		}
+	}
+
+	if len(n) < 8 || len(n) > 15 {
+		return "", fmt.Errorf("invalid phone number %q: %d digits, want 8-15", number, len(n))
+	}
+	return n, nil
+}
@@ -0,0 +1,92 @@
+package smpp
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+)
+
+// EncodingStrategy selects the encodings EncodeText may choose from
+type EncodingStrategy int
+
+const (
+	// EncodeGSM7OrUCS2 uses the GSM 7-bit default alphabet when it covers
+	// the text and UCS2 otherwise. Every SMSC supports both.
+	EncodeGSM7OrUCS2 EncodingStrategy = iota
+
+	// EncodeGSM7Latin1UCS2 also considers Latin-1 (data_coding 0x03), so
+	// text with a few characters outside GSM7, such as 'ê' or 'õ', costs
+	// one octet per character instead of two. Only use it with SMSCs that
+	// support Latin-1.
+	EncodeGSM7Latin1UCS2
+)
+
+// EncodeText encodes text for short_message and returns the standard
+// data_coding value to send it with. It tries GSM7, then Latin-1 if the
+// strategy allows it, then UCS2, and picks the encoding needing the fewest
+// segments, preferring the earlier one on a tie. UCS2 covers any text.
+func EncodeText(text string, strategy EncodingStrategy) ([]byte, byte) {
+	var body []byte
+	var dataCoding byte
+	parts := 0
+	consider := func(b []byte, dc byte) {
+		if n := textSegments(CharsetOf(dc), len(b)); parts == 0 || n < parts {
+			body, dataCoding, parts = b, dc, n
+		}
+	}
+
+	if b, ok := encodeGSM7(text); ok {
+		consider(b, DataCodingGSM7)
+	}
+	if strategy == EncodeGSM7Latin1UCS2 {
+		if b, ok := encodeLatin1(text); ok {
+			consider(b, DataCodingLatin1)
+		}
+	}
+	consider(encodeUCS2(text), DataCodingUCS2)
+	return body, dataCoding
+}
+
+// EncodeText encodes text with the strategy set by WithEncodingStrategy.
+// Put the result in Message and DataCoding; SendLongSMS splits it as the
+// data_coding requires.
+func (c *Client) EncodeText(text string) ([]byte, byte) {
+	return EncodeText(text, c.encoding)
+}
+
+// textSegments is the number of messages n octets of cs-encoded text take
+func textSegments(cs Charset, n int) int {
+	single, part := 140, binarySegmentOctets
+	switch cs {
+	case CharsetGSM7:
+		single, part = 160, gsm7SegmentOctets
+	case CharsetUCS2:
+		part = ucs2SegmentOctets
+	}
+	if n <= single {
+		return 1
+	}
+	return (n + part - 1) / part
+}
+
+// encodeLatin1 encodes s as ISO-8859-1, reporting false if s has a
+// character beyond U+00FF
+func encodeLatin1(s string) ([]byte, bool) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xFF {
+			return nil, false
+		}
+		b = append(b, byte(r))
+	}
+	return b, true
+}
+
+// encodeUCS2 encodes s as UTF-16BE, which SMSCs accept as UCS2
+func encodeUCS2(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.BigEndian.PutUint16(b[2*i:], u)
+	}
+	return b
+}
@@ -0,0 +1,67 @@
+package smpp
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewClientFromEnv creates a client from environment variables:
+//
+//	SMPP_HOST, SMPP_PORT, SMPP_SYSTEM_ID, SMPP_PASSWORD  required
+//	SMPP_TLS              connect over TLS (true/false)
+//	SMPP_CONNECT_TIMEOUT  dial timeout, e.g. "10s"
+//	SMPP_READ_TIMEOUT     read/write timeout, e.g. "30s"
+//
+// opts are applied after the settings taken from the environment.
+func NewClientFromEnv(opts ...Option) (*Client, error) {
+	var missing []string
+	required := func(name string) string {
+		v := os.Getenv(name)
+		if v == "" {
+			missing = append(missing, name)
+		}
+		return v
+	}
+	host := required("SMPP_HOST")
+	portStr := required("SMPP_PORT")
+	systemID := required("SMPP_SYSTEM_ID")
+	password := required("SMPP_PASSWORD")
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required environment variables: %s", strings.Join(missing, ", "))
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid SMPP_PORT %q", portStr)
+	}
+
+	var envOpts []Option
+	if v := os.Getenv("SMPP_TLS"); v != "" {
+		useTLS, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMPP_TLS %q: %w", v, err)
+		}
+		if useTLS {
+			envOpts = append(envOpts, WithTLS(nil))
+		}
+	}
+	if v := os.Getenv("SMPP_CONNECT_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMPP_CONNECT_TIMEOUT %q: %w", v, err)
+		}
+		envOpts = append(envOpts, WithConnectTimeout(d))
+	}
+	if v := os.Getenv("SMPP_READ_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMPP_READ_TIMEOUT %q: %w", v, err)
+		}
+		envOpts = append(envOpts, WithReadTimeout(d))
+	}
+
+	return NewClient(host, port, systemID, password, append(envOpts, opts...)...), nil
+}
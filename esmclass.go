@@ -0,0 +1,64 @@
+package smpp
+
+// esm_class bit layout
+const (
+	esmClassModeMask  = 0x03 // bits 1-0: messaging mode
+	esmClassTypeMask  = 0x3C // bits 5-2: message type
+	esmClassUDHI      = 0x40 // bit 6: short_message starts with a UDH
+	esmClassReplyPath = 0x80 // bit 7: set reply path
+)
+
+// MessagingMode is the delivery mode requested in esm_class
+type MessagingMode byte
+
+const (
+	ModeDefault         MessagingMode = 0x00 // SMSC default, usually store and forward
+	ModeDatagram        MessagingMode = 0x01 // best effort, no SMSC storage
+	ModeForward         MessagingMode = 0x02 // transaction mode, result in submit_sm_resp
+	ModeStoreAndForward MessagingMode = 0x03 // stored until delivered or expired
+)
+
+// MessageType is the esm_class message type. Receipts and acknowledgements
+// arriving in deliver_sm use the same bits.
+type MessageType byte
+
+const (
+	MessageTypeDefault           MessageType = 0x00 // normal message
+	MessageTypeDeliveryReceipt   MessageType = 0x04 // SMSC delivery receipt (deliver_sm only)
+	MessageTypeDeliveryAck       MessageType = 0x08 // SME delivery acknowledgement
+	MessageTypeManualAck         MessageType = 0x10 // SME manual/user acknowledgement
+	MessageTypeConversationAbort MessageType = 0x18 // conversation abort (deliver_sm only)
+	MessageTypeIntermediate      MessageType = 0x20 // intermediate delivery notification (deliver_sm only)
+)
+
+// EsmClass describes the esm_class field of a submit. SendSMS adds the
+// UDHI bit for concatenated parts on its own, so it only needs setting
+// here when Message carries a caller-built header.
+type EsmClass struct {
+	Mode      MessagingMode
+	Type      MessageType
+	UDHI      bool
+	ReplyPath bool // ask the SMSC to route a reply through the same SMSC
+}
+
+// Encode packs e into an esm_class byte
+func (e EsmClass) Encode() byte {
+	b := byte(e.Mode)&esmClassModeMask | byte(e.Type)&esmClassTypeMask
+	if e.UDHI {
+		b |= esmClassUDHI
+	}
+	if e.ReplyPath {
+		b |= esmClassReplyPath
+	}
+	return b
+}
+
+// ParseEsmClass unpacks an esm_class byte
+func ParseEsmClass(b byte) EsmClass {
+	return EsmClass{
+		Mode:      MessagingMode(b & esmClassModeMask),
+		Type:      MessageType(b & esmClassTypeMask),
+		UDHI:      b&esmClassUDHI != 0,
+		ReplyPath: b&esmClassReplyPath != 0,
+	}
+}
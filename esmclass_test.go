@@ -0,0 +1,26 @@
+package smpp
+
+import "testing"
+
+func TestBinaryLeavesEsmClassType(t *testing.T) {
+	submits := make(chan *DeliverSM, 1)
+	smsc := newTestSMSC(t, recordSubmits(submits))
+	c := smsc.client()
+	connect(t, c)
+
+	for _, typ := range []MessageType{MessageTypeDefault, MessageTypeManualAck} {
+		msg := &SMSMessage{
+			SourceAddr: "Shop",
+			DestAddr:   "998901234567",
+			Message:    []byte{0xCA, 0xFE},
+			IsBinary:   true,
+			EsmClass:   EsmClass{Type: typ},
+		}
+		if _, err := c.SendSMS(msg); err != nil {
+			t.Fatal(err)
+		}
+		if got := (<-submits).EsmClass.Type; got != typ {
+			t.Fatalf("message type = %#x, want %#x", got, typ)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package smpp
+
+// gsm7Basic is the GSM 03.38 default alphabet, indexed by septet
+var gsm7Basic = []rune("@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞ\x1bÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà")
+
+// gsm7Escape introduces a character from the extension table
+const gsm7Escape = 0x1B
+
+// gsm7Extension is the GSM 03.38 extension table, reached through the
+// escape septet
+var gsm7Extension = map[byte]rune{
+	0x0A: '\f', 0x14: '^', 0x28: '{', 0x29: '}', 0x2F: '\\',
+	0x3C: '[', 0x3D: '~', 0x3E: ']', 0x40: '|', 0x65: '€',
+}
+
+// decodeGSM7 decodes unpacked GSM 7-bit text, one septet per octet as SMPP
+// carries it. Unknown extension codes decode as their basic character, as
+// the spec prescribes.
+func decodeGSM7(b []byte) string {
+	runes := make([]rune, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		c := b[i] & 0x7F
+		if c == gsm7Escape && i+1 < len(b) {
+			i++
+			next := b[i] & 0x7F
+			if r, ok := gsm7Extension[next]; ok {
+				runes = append(runes, r)
+			} else {
+				runes = append(runes, gsm7Basic[next])
+			}
+			continue
+		}
+		runes = append(runes, gsm7Basic[c])
+	}
+	return string(runes)
+}
+
+// gsm7Encode maps each character to its septet(s), built from the decode
+// tables. The escape septet itself has no character.
+var gsm7Encode = func() map[rune][]byte {
+	m := make(map[rune][]byte, len(gsm7Basic)+len(gsm7Extension))
+	for i, r := range gsm7Basic {
+		if i != gsm7Escape {
+			m[r] = []byte{byte(i)}
+		}
+	}
+	for c, r := range gsm7Extension {
+		m[r] = []byte{gsm7Escape, c}
+	}
+	return m
+}()
+
+// encodeGSM7 encodes s as unpacked GSM 7-bit septets, one per octet. It
+// reports false if s has a character outside the default alphabet and its
+// extension table.
+func encodeGSM7(s string) ([]byte, bool) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		septets, ok := gsm7Encode[r]
+		if !ok {
+			return nil, false
+		}
+		b = append(b, septets...)
+	}
+	return b, true
+}
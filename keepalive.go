@@ -0,0 +1,68 @@
+package smpp
+
+import "fmt"
+
+// startKeepalive starts sending enquire_link every enquireInterval for the
+// session that just bound, replacing the loop of any earlier session
+func (c *Client) startKeepalive() {
+	if c.enquireInterval <= 0 {
+		return
+	}
+	c.keepaliveMu.Lock()
+	defer c.keepaliveMu.Unlock()
+	c.stopKeepaliveLocked()
+	stop := make(chan struct{})
+	c.keepaliveStop = stop
+	go c.keepalive(stop)
+}
+
+// stopKeepalive ends the keepalive loop, if one runs. It does not take
+// c.mu, so a shutdown need not wait for an exchange in progress.
+func (c *Client) stopKeepalive() {
+	c.keepaliveMu.Lock()
+	defer c.keepaliveMu.Unlock()
+	c.stopKeepaliveLocked()
+}
+
+// stopKeepaliveLocked is stopKeepalive for callers holding c.keepaliveMu
+func (c *Client) stopKeepaliveLocked() {
+	if c.keepaliveStop != nil {
+		close(c.keepaliveStop)
+		c.keepaliveStop = nil
+	}
+}
+
+// keepalive sends enquire_link each interval until stop closes. A link
+// that goes unanswered for enquireTimeout is treated as lost, since a
+// half-open TCP connection may otherwise go unnoticed until the next
+// submit.
+func (c *Client) keepalive(stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-c.clock.After(c.enquireInterval):
+		}
+
+		timeout := c.enquireTimeout
+		if timeout <= 0 {
+			timeout = c.conn.readTimeout
+		}
+		resp, err := c.roundTripWithin(newPDU(ENQUIRE_LINK, c.nextSequence()), timeout)
+		if err == nil {
+			if resp.commandStatus != ESME_ROK {
+				c.logger.Warn("smpp: enquire_link refused", "status", resp.commandStatus)
+			}
+			continue
+		}
+
+		select {
+		case <-stop:
+			return // the session ended meanwhile, which explains the failure
+		default:
+		}
+		c.logger.Warn("smpp: enquire_link failed, dropping connection", "error", err)
+		c.connectionLost(fmt.Errorf("enquire_link: %w", err))
+		return
+	}
+}
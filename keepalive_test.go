@@ -0,0 +1,135 @@
+package smpp
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countEnquireLinks returns an SMSC handler counting enquire_link before
+// the default reply
+func countEnquireLinks(n *atomic.Int32) func(*smscConn, *pdu) bool {
+	return func(conn *smscConn, p *pdu) bool {
+		if p.commandID == ENQUIRE_LINK {
+			n.Add(1)
+		}
+		return false
+	}
+}
+
+func TestKeepaliveDuringRapidSubmits(t *testing.T) {
+	for _, mode := range []BindMode{BindTransmitter, BindTransceiver} {
+		t.Run(mode.String(), func(t *testing.T) {
+			var links atomic.Int32
+			smsc := newTestSMSC(t, countEnquireLinks(&links))
+			c := smsc.client(WithBindMode(mode), WithEnquireLink(2*time.Millisecond, time.Second))
+			connect(t, c)
+
+			// Submits and enquire_link interleave on the wire; each must
+			// get its own response
+			concurrently(t, 4, func() {
+				for i := range 50 {
+					id, err := c.SendSMS(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+					if err != nil || id == "" {
+						t.Errorf("submit %d: id %q, %v", i, id, err)
+						return
+					}
+				}
+			})
+			if links.Load() == 0 {
+				t.Fatal("no enquire_link sent during the submits")
+			}
+			if c.State() != StateBound {
+				t.Fatalf("state = %s, want bound", c.State())
+			}
+		})
+	}
+}
+
+func TestKeepaliveDropsUnansweredLink(t *testing.T) {
+	var links atomic.Int32
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID == ENQUIRE_LINK {
+			// Answer the first, then go silent like a half-open link
+			return links.Add(1) > 1
+		}
+		return false
+	})
+	lost := make(chan error, 1)
+	c := smsc.client(WithEnquireLink(20*time.Millisecond, 100*time.Millisecond))
+	c.OnConnectionLost(func(err error) { lost <- err })
+	connect(t, c)
+
+	select {
+	case <-lost:
+	case <-time.After(2 * time.Second):
+		t.Fatal("unanswered enquire_link did not drop the connection")
+	}
+	if links.Load() < 2 {
+		t.Fatalf("%d enquire_link sent, want the answered one and the unanswered one", links.Load())
+	}
+	if c.State() != StateDisconnected {
+		t.Fatalf("state = %s, want disconnected", c.State())
+	}
+}
+
+func TestKeepaliveFollowsClock(t *testing.T) {
+	var links atomic.Int32
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID == ENQUIRE_LINK {
+			return links.Add(1) > 1 // answer only the first
+		}
+		return false
+	})
+	clock := newFakeClock()
+	lost := make(chan error, 1)
+	c := smsc.client(WithBindMode(BindTransceiver), WithClock(clock), WithEnquireLink(time.Minute, 10*time.Second))
+	c.OnConnectionLost(func(err error) { lost <- err })
+	connect(t, c)
+
+	// Nothing is sent until a full interval has passed on the clock
+	clock.awaitAfter(t, time.Minute)
+	clock.advance(59 * time.Second)
+	time.Sleep(20 * time.Millisecond)
+	if links.Load() != 0 {
+		t.Fatal("enquire_link sent before the interval elapsed")
+	}
+	clock.advance(time.Second)
+	waitFor(t, "the first enquire_link", func() bool { return links.Load() == 1 })
+
+	// The next goes unanswered, and only its timeout on the clock drops
+	// the link
+	clock.awaitAfter(t, time.Minute)
+	clock.advance(time.Minute)
+	clock.awaitAfter(t, 10*time.Second)
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case err := <-lost:
+		t.Fatalf("connection lost before the enquire_link timeout: %v", err)
+	default:
+	}
+	clock.advance(10 * time.Second)
+	select {
+	case <-lost:
+	case <-time.After(2 * time.Second):
+		t.Fatal("unanswered enquire_link did not drop the connection")
+	}
+	if links.Load() != 2 {
+		t.Fatalf("%d enquire_link sent, want 2", links.Load())
+	}
+}
+
+func TestKeepaliveStopsWithSession(t *testing.T) {
+	var links atomic.Int32
+	smsc := newTestSMSC(t, countEnquireLinks(&links))
+	c := smsc.client(WithEnquireLink(10*time.Millisecond, time.Second))
+	connect(t, c)
+	waitFor(t, "an enquire_link", func() bool { return links.Load() > 0 })
+
+	c.Disconnect()
+	sent := links.Load()
+	time.Sleep(50 * time.Millisecond)
+	if links.Load() != sent {
+		t.Fatal("enquire_link sent after disconnecting")
+	}
+}
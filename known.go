@@ -0,0 +1,68 @@
+package smpp
+
+import "slices"
+
+// CommandInfo describes a command ID the library understands
+type CommandInfo struct {
+	ID          uint32
+	Name        string
+	Description string
+}
+
+// TLVInfo describes an optional parameter tag the library understands
+type TLVInfo struct {
+	Tag         uint16
+	Name        string
+	Description string
+}
+
+var knownCommands = []CommandInfo{
+	{GENERIC_NACK, "generic_nack", "negative acknowledgement of a PDU that could not be processed"},
+	{BIND_RECEIVER, "bind_receiver", "opens a session for receiving messages"},
+	{BIND_RECEIVER_RESP, "bind_receiver_resp", "response to bind_receiver"},
+	{BIND_TRANSMITTER, "bind_transmitter", "opens a session for submitting messages"},
+	{BIND_TRANSMITTER_RESP, "bind_transmitter_resp", "response to bind_transmitter"},
+	{QUERY_SM, "query_sm", "queries the state of a submitted message"},
+	{QUERY_SM_RESP, "query_sm_resp", "response to query_sm carrying the message state"},
+	{SUBMIT_SM, "submit_sm", "submits a short message for delivery"},
+	{SUBMIT_SM_RESP, "submit_sm_resp", "response to submit_sm carrying the message ID"},
+	{DELIVER_SM, "deliver_sm", "delivers a mobile-originated message or delivery receipt"},
+	{DELIVER_SM_RESP, "deliver_sm_resp", "response to deliver_sm"},
+	{UNBIND, "unbind", "ends the session"},
+	{UNBIND_RESP, "unbind_resp", "response to unbind"},
+	{REPLACE_SM, "replace_sm", "replaces a submitted message that is not yet delivered"},
+	{REPLACE_SM_RESP, "replace_sm_resp", "response to replace_sm"},
+	{BIND_TRANSCEIVER, "bind_transceiver", "opens a session for submitting and receiving messages"},
+	{BIND_TRANSCEIVER_RESP, "bind_transceiver_resp", "response to bind_transceiver"},
+	{ENQUIRE_LINK, "enquire_link", "checks that the peer is alive"},
+	{ENQUIRE_LINK_RESP, "enquire_link_resp", "response to enquire_link"},
+	{ALERT_NOTIFICATION, "alert_notification", "reports that a mobile subscriber has become available"},
+	{DATA_SM, "data_sm", "transfers a message with its content in message_payload"},
+	{DATA_SM_RESP, "data_sm_resp", "response to data_sm carrying the message ID"},
+}
+
+var knownTLVs = []TLVInfo{
+	{TLV_RECEIPTED_MESSAGE_ID, "receipted_message_id", "SMSC message ID a delivery receipt refers to"},
+	{TLV_NUMBER_OF_MESSAGES, "number_of_messages", "number of messages the SMSC generates for a submission"},
+	{TLV_SOURCE_PORT, "source_port", "application port of the sender"},
+	{TLV_DESTINATION_PORT, "destination_port", "application port of the recipient"},
+	{TLV_NETWORK_ERROR_CODE, "network_error_code", "network error behind a failed delivery"},
+	{TLV_MS_AVAILABILITY_STATUS, "ms_availability_status", "subscriber availability in alert_notification"},
+	{TLV_MESSAGE_PAYLOAD, "message_payload", "message content of up to 64K octets, instead of short_message"},
+	{TLV_MESSAGE_STATE, "message_state", "message state reported in a delivery receipt"},
+	{TLV_MORE_MESSAGES, "more_messages_to_send", "another message for the same destination follows"},
+	{TLV_ITS_REPLY_TYPE, "its_reply_type", "reply type expected by an interactive teleservice message"},
+	{TLV_ITS_SESSION_INFO, "its_session_info", "session and sequence of an interactive teleservice message"},
+}
+
+// KnownCommands lists the command IDs the library understands, in
+// a stable order. Vendor commands added with RegisterCommand are not
+// included.
+func KnownCommands() []CommandInfo {
+	return slices.Clone(knownCommands)
+}
+
+// KnownTLVs lists the optional parameter tags the library reads or writes
+func KnownTLVs() []TLVInfo {
+	return slices.Clone(knownTLVs)
+}
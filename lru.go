@@ -0,0 +1,98 @@
+package smpp
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lru is a fixed-capacity map that evicts the least recently used key. It
+// is not safe for concurrent use; callers hold their own lock.
+type lru[K comparable, V any] struct {
+	capacity int
+	order    *list.List // front is most recently used
+	items    map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func newLRU[K comparable, V any](capacity int) *lru[K, V] {
+	return &lru[K, V]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+	}
+}
+
+// get returns the value for key and marks it as recently used
+func (l *lru[K, V]) get(key K) (V, bool) {
+	if e, ok := l.items[key]; ok {
+		l.order.MoveToFront(e)
+		return e.Value.(*lruEntry[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// put stores value under key, evicting the oldest entry when full
+func (l *lru[K, V]) put(key K, value V) {
+	if e, ok := l.items[key]; ok {
+		e.Value.(*lruEntry[K, V]).value = value
+		l.order.MoveToFront(e)
+		return
+	}
+	if l.order.Len() >= l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruEntry[K, V]).key)
+	}
+	l.items[key] = l.order.PushFront(&lruEntry[K, V]{key, value})
+}
+
+// idTracker remembers recently seen IDs in a bounded LRU
+type idTracker struct {
+	mu  sync.Mutex
+	ids *lru[string, struct{}]
+}
+
+func newIDTracker(size int) *idTracker {
+	return &idTracker{ids: newLRU[string, struct{}](size)}
+}
+
+// seen records id and reports whether it was already present
+func (t *idTracker) seen(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.ids.get(id); ok {
+		return true
+	}
+	t.ids.put(id, struct{}{})
+	return false
+}
+
+// idMap remembers, in a bounded LRU, the ID an SMSC issued for each ID
+// the client handed out in its place
+type idMap struct {
+	mu  sync.Mutex
+	ids *lru[string, string]
+}
+
+func newIDMap(size int) *idMap {
+	return &idMap{ids: newLRU[string, string](size)}
+}
+
+// put records that id stands for original
+func (m *idMap) put(id, original string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ids.put(id, original)
+}
+
+// get returns the original of id, if still remembered
+func (m *idMap) get(id string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ids.get(id)
+}
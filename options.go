@@ -0,0 +1,529 @@
+package smpp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithProfile validates every outgoing message against the named profile
+// before it is sent. The profile is looked up at send time, so it may be
+// registered after the client is created.
+func WithProfile(name string) Option {
+	return func(c *Client) {
+		c.profile = name
+	}
+}
+
+// WithSequenceGenerator replaces the default atomic counter used to number
+// outgoing PDUs, e.g. to partition sequence numbers per tenant
+func WithSequenceGenerator(g SequenceGenerator) Option {
+	return func(c *Client) {
+		c.sequence = g
+	}
+}
+
+// WithLocalAddr makes the client originate its connection from the given
+// local IP (or IP:port), for SMSCs that whitelist by source address on
+// multi-homed hosts. The address is validated when connecting.
+func WithLocalAddr(addr string) Option {
+	return func(c *Client) {
+		c.conn.localAddr = addr
+	}
+}
+
+// defaultMaxShortMessageOctets is the largest short_message allowed by the spec
+const defaultMaxShortMessageOctets = 254
+
+// minShortMessageOctets leaves room for one UCS2 character after the
+// concatenation UDH, so SendLongSMS can always make progress
+const minShortMessageOctets = udhConcatLength + 2
+
+// WithMaxShortMessageOctets lowers the largest short_message the client
+// sends, for SMSCs that reject anything above e.g. 140 octets. Both the
+// SendSMS length check and SendLongSMS segmentation honour it. Values
+// outside 8-254 are ignored.
+func WithMaxShortMessageOctets(n int) Option {
+	return func(c *Client) {
+		if n >= minShortMessageOctets && n <= defaultMaxShortMessageOctets {
+			c.maxShortMessageOctets = n
+		}
+	}
+}
+
+// WithE164Normalization makes SendSMS canonicalize every DestAddr with
+// NormalizeE164 before sending, using defaultRegion for national numbers.
+// Unparseable numbers fail with a validation error instead of reaching the
+// SMSC.
+func WithE164Normalization(defaultRegion string) Option {
+	return func(c *Client) {
+		c.region = defaultRegion
+	}
+}
+
+// WithConnectTimeout sets how long dialing and the TLS handshake may take
+func WithConnectTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.conn.connectTimeout = d
+	}
+}
+
+// WithReadTimeout sets the deadline for each PDU read and write
+func WithReadTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.conn.readTimeout = d
+	}
+}
+
+// WithTLS makes Connect always use TLS with the given config, whatever its
+// useTLS argument. A nil config uses the default TLS settings, which verify
+// the SMSC's certificate against the host name.
+func WithTLS(config *tls.Config) Option {
+	return func(c *Client) {
+		c.useTLS = true
+		c.tlsConfig = config
+	}
+}
+
+// WithAccounting counts every segment the SMSC accepts into a, by source
+// and destination address. One Accounting may be shared by several clients.
+func WithAccounting(a *Accounting) Option {
+	return func(c *Client) {
+		c.accounting = a
+	}
+}
+
+// WithMaxPayloadOctets caps the message_payload size for SMSCs that accept
+// less than the 64KB a TLV can hold. SendSMS rejects larger payloads and
+// SendLongSMS falls back to concatenated submit_sm for them. Values
+// outside 1-65535 are ignored.
+func WithMaxPayloadOctets(n int) Option {
+	return func(c *Client) {
+		if n >= 1 && n <= maxTLVLength {
+			c.maxPayloadOctets = n
+		}
+	}
+}
+
+// CredentialEncoding selects how system_id and password are encoded in bind
+type CredentialEncoding int
+
+const (
+	CredentialsRaw    CredentialEncoding = iota // bytes of the Go string, fine for ASCII
+	CredentialsLatin1                           // ISO-8859-1, one octet per character
+)
+
+// encode converts s to the wire form of the encoding
+func (e CredentialEncoding) encode(s string) (string, error) {
+	if e != CredentialsLatin1 {
+		return s, nil
+	}
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xFF {
+			return "", fmt.Errorf("character %q cannot be encoded as Latin-1", r)
+		}
+		b = append(b, byte(r))
+	}
+	return string(b), nil
+}
+
+// WithCredentialEncoding sets the encoding of system_id and password, for
+// SMSCs that expect Latin-1 credentials with accented characters
+func WithCredentialEncoding(e CredentialEncoding) Option {
+	return func(c *Client) {
+		c.credEnc = e
+	}
+}
+
+// NetworkType identifies the SMSC's network technology, which determines
+// the meaning and range of priority_flag
+type NetworkType int
+
+const (
+	NetworkGSM     NetworkType = iota // priority 0-3
+	NetworkANSI136                    // priority 0-4
+	NetworkIS95                       // priority 0-4
+)
+
+func (n NetworkType) String() string {
+	switch n {
+	case NetworkANSI136:
+		return "ANSI-136"
+	case NetworkIS95:
+		return "IS-95"
+	default:
+		return "GSM"
+	}
+}
+
+// maxPriority returns the largest priority_flag valid on the network
+func (n NetworkType) maxPriority() byte {
+	if n == NetworkANSI136 || n == NetworkIS95 {
+		return 4
+	}
+	return 3
+}
+
+// WithNetworkType sets the network the SMSC serves, so priority_flag is
+// validated against that network's range. The default is NetworkGSM.
+func WithNetworkType(n NetworkType) Option {
+	return func(c *Client) {
+		c.network = n
+	}
+}
+
+// WithWordBoundaries makes SendLongSMS end text parts at spaces rather than
+// mid-word, for handsets that show parts separately. A part is only cut
+// mid-word when a single word fills it, and word splitting is skipped
+// entirely if it would take more parts than a hard split.
+func WithWordBoundaries() Option {
+	return func(c *Client) {
+		c.wordBoundaries = true
+	}
+}
+
+// WithRateLimit limits submits to tps per second. The effective rate adapts
+// to the SMSC: every ESME_RTHROTTLED response halves it and every accepted
+// submit raises it slowly back toward tps.
+func WithRateLimit(tps float64) Option {
+	return func(c *Client) {
+		if tps > 0 {
+			c.limiter = newRateLimiter(tps)
+		}
+	}
+}
+
+// WithRateLimitRamp makes the rate limit restart at fraction (0-1] of its
+// ceiling after every bind, climbing back as submits are accepted, so a
+// reconnect does not hit the SMSC at full speed. The token bucket is always
+// drained on bind, whatever the fraction.
+func WithRateLimitRamp(fraction float64) Option {
+	return func(c *Client) {
+		if fraction > 0 && fraction <= 1 {
+			c.rampFraction = fraction
+		}
+	}
+}
+
+// WithAddressRange sets the addr_ton, addr_npi and address_range sent in
+// bind, so the SMSC interprets the range with the right numbering plan.
+// The default is 0/0 with an empty range.
+func WithAddressRange(ton, npi byte, addressRange string) Option {
+	return func(c *Client) {
+		c.addrTON = ton
+		c.addrNPI = npi
+		c.addressRange = addressRange
+	}
+}
+
+// WithDataCodingMap overrides the data_coding values the client emits for
+// each kind of content, for operators that deviate from GSM 03.38
+func WithDataCodingMap(m DataCodingMap) Option {
+	return func(c *Client) {
+		c.dataCodings = m
+	}
+}
+
+// WithInsecureSkipVerify disables TLS certificate verification when skip is
+// true. This exposes the session to man-in-the-middle attacks, so it is only
+// meant for test SMSCs with self-signed certificates; a warning is logged on
+// every TLS connect while it is in effect.
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(c *Client) {
+		c.insecureSkipVerify = skip
+	}
+}
+
+// WithLogger sets the logger for warnings; by default nothing is logged
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// AddressOverflow selects what happens to source or destination addresses
+// longer than the 20 characters the spec allows
+type AddressOverflow int
+
+const (
+	AddressOverflowReject   AddressOverflow = iota // fail the submit with an error
+	AddressOverflowTruncate                        // send the first 20 characters and log a warning
+)
+
+// WithAddressOverflow chooses between failing fast on over-length
+// addresses (the default) and best-effort truncation
+func WithAddressOverflow(mode AddressOverflow) Option {
+	return func(c *Client) {
+		c.addressOverflow = mode
+	}
+}
+
+// WithDestinationLimit refuses more than n submits to the same destination
+// within window, returning ErrDestinationThrottled, to avoid tripping SMSC
+// anti-spam rules. A long message counts as one submit, checked before its
+// first part is sent. Up to 10000 recent destinations are tracked.
+func WithDestinationLimit(n int, window time.Duration) Option {
+	return func(c *Client) {
+		if n > 0 && window > 0 {
+			c.destLimiter = newDestLimiter(n, window)
+		}
+	}
+}
+
+// WithUnboundQueue lets up to size submits issued while the client is not
+// bound, such as during startup or a reconnect, wait for the next
+// successful bind instead of failing at once. A submit that is still
+// waiting after timeout fails, as does one arriving when the queue is full.
+func WithUnboundQueue(size int, timeout time.Duration) Option {
+	return func(c *Client) {
+		if size > 0 && timeout > 0 {
+			c.queueSlots = make(chan struct{}, size)
+			c.queueTimeout = timeout
+		}
+	}
+}
+
+// defaultInboundBuffer is the number of deliver_sm PDUs held until the
+// handler takes them
+const defaultInboundBuffer = 64
+
+// WithInboundBuffer sets how many deliver_sm PDUs wait for the OnDeliverSM
+// handler, 64 by default. Beyond that they are refused with ESME_RMSGQFUL
+// so the SMSC redelivers later, and counted in Stats().RefusedInbound;
+// with zero a deliver_sm is only accepted while a handler worker is idle.
+func WithInboundBuffer(n int) Option {
+	return func(c *Client) {
+		if n >= 0 {
+			c.inboundBuffer = n
+		}
+	}
+}
+
+// WithDeliverWorkers runs the OnDeliverSM handler on n goroutines, so a
+// slow handler does not hold up the deliver_sm behind it; each is still
+// answered once its own call returns. With more than one worker calls
+// overlap and complete in no particular order, so receipts for the same
+// message may be handled out of order. The default is 1, which keeps
+// arrival order. Values below 1 are ignored.
+func WithDeliverWorkers(n int) Option {
+	return func(c *Client) {
+		if n >= 1 {
+			c.deliverWorkers = n
+		}
+	}
+}
+
+// WithSuccessStatus decides which submit_sm_resp command_status values
+// count as an accepted submit, for SMSCs that report vendor warnings such
+// as "queued" with a non-zero status. The default accepts only ESME_ROK.
+func WithSuccessStatus(success func(status uint32) bool) Option {
+	return func(c *Client) {
+		if success != nil {
+			c.successStatus = success
+		}
+	}
+}
+
+// defaultMaxSegments caps the parts SendLongSMS sends for one message
+const defaultMaxSegments = 10
+
+// WithMaxSegments sets how many parts SendLongSMS may split a message into
+// before refusing it, 10 by default, as a guard against runaway costs from
+// accidentally huge messages. The protocol allows up to 255.
+func WithMaxSegments(n int) Option {
+	return func(c *Client) {
+		if n >= 1 && n <= maxSegments {
+			c.maxSegments = n
+		}
+	}
+}
+
+// WithClock replaces the real clock driving the client's timers, so tests
+// can control time deterministically
+func WithClock(clock Clock) Option {
+	return func(c *Client) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// WithQueueFullCooldown sets how long submits pause after the SMSC answers
+// ESME_RMSGQFUL, one second by default. Each such response also halves the
+// rate limit, if one is set, which then recovers as submits are accepted.
+func WithQueueFullCooldown(d time.Duration) Option {
+	return func(c *Client) {
+		if d >= 0 {
+			c.queueFullCooldown = d
+		}
+	}
+}
+
+// WithAutoPayload makes SendLongSMS send content longer than threshold
+// octets as a single submit_sm with message_payload instead of
+// concatenated parts, when smscSupportsPayload says the SMSC accepts that
+// TLV. Content up to threshold still goes in short_message; a threshold of
+// zero uses the single-part capacity (160 GSM 7-bit characters or 140
+// octets). If the SMSC does not support message_payload the option has no
+// effect and long content is always segmented.
+func WithAutoPayload(threshold int, smscSupportsPayload bool) Option {
+	return func(c *Client) {
+		c.autoPayload = smscSupportsPayload
+		c.autoPayloadThreshold = max(threshold, 0)
+	}
+}
+
+// WithSessionRecorder records every PDU sent and received, with its
+// direction and timestamp, to w for post-mortem analysis; read the
+// recording back with ReadSessionRecord. Write errors are ignored.
+func WithSessionRecorder(w io.Writer) Option {
+	return func(c *Client) {
+		if w != nil {
+			c.conn.recorder = &recorder{w: w}
+		}
+	}
+}
+
+// WithRecentPDUs keeps the headers of the last size PDUs sent and
+// received in memory, for RecentPDUs to dump after an error. Unlike
+// WithSessionRecorder no bodies are kept, so it is cheap to leave on.
+func WithRecentPDUs(size int) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.conn.recent = newPDURing(size)
+		}
+	}
+}
+
+// WithReconnectLimiter makes ConnectWithRetry wait for a slot in l before
+// each connect attempt. Give the same limiter to every client connecting to
+// one SMSC to cap how many reconnect at once.
+func WithReconnectLimiter(l *ReconnectLimiter) Option {
+	return func(c *Client) {
+		c.reconnects = l
+	}
+}
+
+// WithOTPDefaults sets the sender, text template, validity and flash mode
+// SendOTP uses
+func WithOTPDefaults(d OTPDefaults) Option {
+	return func(c *Client) {
+		c.otpDefaults = d
+	}
+}
+
+// WithDuplicateDetection remembers the last size message IDs returned by
+// the SMSC and logs a warning, counted in Stats().DuplicateMessageIDs,
+// when a submit response repeats one. Such SMSCs break receipt
+// correlation.
+func WithDuplicateDetection(size int) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.recentIDs = newIDTracker(size)
+		}
+	}
+}
+
+// WithAutoSourceAddressing picks source_addr_ton/npi from SourceAddr for
+// messages that leave both at zero, so one client can send from long codes
+// and alphanumeric sender IDs. A nil classify uses ClassifySourceAddr.
+func WithAutoSourceAddressing(classify SourceAddrClassifier) Option {
+	return func(c *Client) {
+		if classify == nil {
+			classify = ClassifySourceAddr
+		}
+		c.classifySource = classify
+	}
+}
+
+// WithEncodingStrategy sets the encodings Client.EncodeText chooses from.
+// The default, EncodeGSM7OrUCS2, leaves out Latin-1 since not every SMSC
+// supports it.
+func WithEncodingStrategy(s EncodingStrategy) Option {
+	return func(c *Client) {
+		c.encoding = s
+	}
+}
+
+// WithDedupWindow makes SendSMS suppress a message identical in source,
+// destination and content to one the SMSC accepted less than window ago,
+// returning the earlier message ID instead of sending again. It guards
+// against retry bugs double-sending, say, an OTP. At most size messages
+// are remembered; concurrent identical sends are not caught.
+func WithDedupWindow(window time.Duration, size int) Option {
+	return func(c *Client) {
+		if window > 0 && size > 0 {
+			c.dedup = newDedup(window, size)
+		}
+	}
+}
+
+// WithQuirks enables workarounds for known SMSC bugs; see Quirks
+func WithQuirks(q Quirks) Option {
+	return func(c *Client) {
+		c.quirks = q
+		c.hexIDs = nil
+		if q.has(QuirkHexMessageIDs) {
+			c.hexIDs = newIDMap(hexMessageIDs)
+		}
+	}
+}
+
+// WithWindow sets how many pipelined submits, from Pipeline and SendBatch,
+// may await a response at once across the client; Submit blocks while the
+// window is full. The default is 10. Values below 1 are ignored.
+func WithWindow(n int) Option {
+	return func(c *Client) {
+		if n >= 1 {
+			c.windowSize = n
+		}
+	}
+}
+
+// WithEnquireLink sends enquire_link every interval while bound, so SMSCs
+// that drop idle sessions keep this one, and drops the connection if no
+// enquire_link_resp arrives within timeout. Zero timeout uses the read
+// timeout.
+func WithEnquireLink(interval, timeout time.Duration) Option {
+	return func(c *Client) {
+		c.enquireInterval = interval
+		c.enquireTimeout = timeout
+	}
+}
+
+// WithReceiptGrace keeps a transceiver or receiver session bound for d
+// after Shutdown has drained the submits in flight, reading deliver_sm but
+// refusing new submits, so delivery receipts for messages already sent are
+// not lost to the unbind. The Shutdown context still bounds the wait. Zero,
+// the default, unbinds as soon as the submits are drained.
+func WithReceiptGrace(d time.Duration) Option {
+	return func(c *Client) {
+		c.receiptGrace = d
+	}
+}
+
+// WithIdleTimeout drops the connection when the read loop receives no PDU
+// at all for d. The deadline restarts with every PDU, enquire_link and
+// responses included, so a quiet but healthy session survives as long as
+// something arrives within d; pair it with WithEnquireLink to make sure
+// something does. Zero, the default, lets the read loop wait indefinitely.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.idleTimeout = d
+	}
+}
+
+// WithBindMode selects the session type to bind as. Receiver and
+// transceiver sessions read the connection continuously to take deliver_sm
+// from the SMSC; a receiver refuses submits.
+func WithBindMode(mode BindMode) Option {
+	return func(c *Client) {
+		c.bindMode = mode
+	}
+}
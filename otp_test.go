@@ -0,0 +1,42 @@
+package smpp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSendOTPEncodesGSM7(t *testing.T) {
+	submits := make(chan *DeliverSM, 1)
+	smsc := newTestSMSC(t, recordSubmits(submits))
+	c := smsc.client(WithOTPDefaults(OTPDefaults{Template: "Kod_%s @"}))
+	connect(t, c)
+
+	if _, err := c.SendOTP("998901234567", "1234"); err != nil {
+		t.Fatal(err)
+	}
+	sm := <-submits
+	// '_' is septet 0x11 and '@' septet 0x00, unlike their ASCII bytes
+	want := []byte{'K', 'o', 'd', 0x11, '1', '2', '3', '4', ' ', 0x00}
+	if !bytes.Equal(sm.Message, want) {
+		t.Fatalf("short_message = % x, want % x", sm.Message, want)
+	}
+	if sm.DataCoding != 0 {
+		t.Fatalf("data_coding = %#x, want 0", sm.DataCoding)
+	}
+}
+
+func TestSendOTPRejectsNonGSM7(t *testing.T) {
+	submits := make(chan *DeliverSM, 1)
+	smsc := newTestSMSC(t, recordSubmits(submits))
+	c := smsc.client(WithOTPDefaults(OTPDefaults{Template: "Код %s"}))
+	connect(t, c)
+
+	if _, err := c.SendOTP("998901234567", "1234"); err == nil {
+		t.Fatal("sent an OTP outside the GSM 7-bit alphabet")
+	}
+	select {
+	case <-submits:
+		t.Fatal("submit_sm reached the SMSC")
+	default:
+	}
+}
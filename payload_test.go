@@ -0,0 +1,25 @@
+package smpp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUsePayloadLeavesShortMessageEmpty(t *testing.T) {
+	submits := make(chan *DeliverSM, 1)
+	smsc := newTestSMSC(t, recordSubmits(submits))
+	c := smsc.client()
+	connect(t, c)
+
+	content := []byte("short enough for short_message")
+	if _, err := c.SendSMS(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: content, UsePayload: true}); err != nil {
+		t.Fatal(err)
+	}
+	sm := <-submits
+	if !sm.FromPayload {
+		t.Fatal("content not sent in message_payload")
+	}
+	if !bytes.Equal(sm.Message, content) || !bytes.Equal(sm.TLVs[TLV_MESSAGE_PAYLOAD], content) {
+		t.Fatalf("message_payload = %q, want %q", sm.TLVs[TLV_MESSAGE_PAYLOAD], content)
+	}
+}
@@ -1,5 +1,31 @@
 package smpp
 
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// pduHeaderLength is the size of the fixed PDU header
+const pduHeaderLength = 16
+
+// maxPDULength bounds the command_length accepted from the SMSC: room for a
+// full message_payload plus the mandatory fields and other TLVs
+const maxPDULength = 128 * 1024
+
+// Maximum sizes of C-octet string fields, including the null terminator
+const (
+	maxSystemIDLength     = 16
+	maxPasswordLength     = 9
+	maxSystemTypeLength   = 13
+	maxAddressRangeLength = 41
+	maxServiceTypeLength  = 6
+	maxAddressLength      = 21
+	maxTimeLength         = 17
+	maxMessageIDLength    = 65
+)
+
 // pdu represents an SMPP Protocol Data Unit
 type pdu struct {
 	commandLength  uint32
@@ -20,6 +46,14 @@ func newPDU(commandID, sequenceNumber uint32) *pdu {
 	}
 }
 
+// responseBit is set in the command_id of every response PDU
+const responseBit uint32 = 0x80000000
+
+// isResponse reports whether the PDU answers a request
+func (p *pdu) isResponse() bool {
+	return p.commandID&responseBit != 0
+}
+
 // write appends raw bytes to the PDU body
 func (p *pdu) write(data []byte) {
 	p.body = append(p.body, data...)
@@ -38,3 +72,112 @@ func (p *pdu) writeString(s string) {
 	// Add null terminator
 	p.body = append(p.body, 0)
 }
+
+// writeTLV appends an optional parameter (tag, length, value) to the PDU body
+func (p *pdu) writeTLV(tag uint16, value []byte) {
+	p.body = append(p.body, byte(tag>>8), byte(tag), byte(len(value)>>8), byte(len(value)))
+	p.body = append(p.body, value...)
+}
+
+// encode serializes the PDU, header and body, setting commandLength
+func (p *pdu) encode() []byte {
+	p.commandLength = uint32(pduHeaderLength + len(p.body))
+
+	buf := make([]byte, p.commandLength)
+	binary.BigEndian.PutUint32(buf[0:4], p.commandLength)
+	binary.BigEndian.PutUint32(buf[4:8], p.commandID)
+	binary.BigEndian.PutUint32(buf[8:12], p.commandStatus)
+	binary.BigEndian.PutUint32(buf[12:16], p.sequenceNumber)
+	copy(buf[pduHeaderLength:], p.body)
+	return buf
+}
+
+// decodePDU parses a complete serialized PDU
+func decodePDU(b []byte) (*pdu, error) {
+	if len(b) < pduHeaderLength {
+		return nil, fmt.Errorf("PDU too short (%d bytes)", len(b))
+	}
+
+	p := &pdu{
+		commandLength:  binary.BigEndian.Uint32(b[0:4]),
+		commandID:      binary.BigEndian.Uint32(b[4:8]),
+		commandStatus:  binary.BigEndian.Uint32(b[8:12]),
+		sequenceNumber: binary.BigEndian.Uint32(b[12:16]),
+	}
+	if int(p.commandLength) != len(b) {
+		return nil, fmt.Errorf("command_length %d does not match PDU size %d", p.commandLength, len(b))
+	}
+	p.body = b[pduHeaderLength:]
+	return p, nil
+}
+
+// writeCString writes s as a C-octet string, refusing values that would not
+// fit in a field of max octets including the null terminator
+func (p *pdu) writeCString(field, s string, max int) error {
+	if len(s) >= max {
+		return fmt.Errorf("%s %q exceeds %d characters", field, s, max-1)
+	}
+	p.writeString(s)
+	return nil
+}
+
+// bodyReader decodes the fields of a received PDU body in order
+type bodyReader struct {
+	b   []byte
+	off int
+}
+
+// octet reads a one-octet integer
+func (r *bodyReader) octet(field string) (byte, error) {
+	if r.off >= len(r.b) {
+		return 0, fmt.Errorf("%s: body truncated", field)
+	}
+	v := r.b[r.off]
+	r.off++
+	return v, nil
+}
+
+// cString reads a NULL-terminated string of at most max octets including
+// the terminator
+func (r *bodyReader) cString(field string, max int) (string, error) {
+	end := bytes.IndexByte(r.b[r.off:], 0)
+	if end < 0 {
+		return "", fmt.Errorf("%s: missing NULL terminator", field)
+	}
+	if end >= max {
+		return "", fmt.Errorf("%s exceeds %d characters", field, max-1)
+	}
+	s := string(r.b[r.off : r.off+end])
+	r.off += end + 1
+	return s, nil
+}
+
+// octets reads n raw octets
+func (r *bodyReader) octets(field string, n int) ([]byte, error) {
+	if n > len(r.b)-r.off {
+		return nil, fmt.Errorf("%s: body truncated", field)
+	}
+	v := r.b[r.off : r.off+n]
+	r.off += n
+	return v, nil
+}
+
+// tlvs reads the optional parameters that make up the rest of the body,
+// keyed by tag. Values alias the body.
+func (r *bodyReader) tlvs() (map[uint16][]byte, error) {
+	tlvs := make(map[uint16][]byte)
+	for r.off < len(r.b) {
+		if len(r.b)-r.off < 4 {
+			return nil, errors.New("optional parameter header truncated")
+		}
+		tag := binary.BigEndian.Uint16(r.b[r.off:])
+		length := int(binary.BigEndian.Uint16(r.b[r.off+2:]))
+		r.off += 4
+		value, err := r.octets(fmt.Sprintf("TLV 0x%04X", tag), length)
+		if err != nil {
+			return nil, err
+		}
+		tlvs[tag] = value
+	}
+	return tlvs, nil
+}
@@ -0,0 +1,199 @@
+package smpp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// defaultWindow is how many pipelined submits may await a response at once
+const defaultWindow = 10
+
+// Pipeline issues submits back-to-back without waiting for each response,
+// then collects all responses in Wait. Over a high-latency link this costs
+// one round trip per window of submits instead of one per message.
+//
+// Responses are read by the background read loop as they arrive, so the
+// rate limiter and queue-full backpressure react during the batch, and the
+// client keeps answering the SMSC and sending keepalives meanwhile. On a
+// transmitter session the first pipeline starts that read loop, which then
+// serves the rest of the session. Submit blocks while the window (see
+// WithWindow) is full. Always call Wait.
+//
+// A Pipeline is not safe for concurrent use: call Submit and Wait from one
+// goroutine. Several goroutines can each run their own pipeline on the
+// same client.
+type Pipeline struct {
+	c       *Client
+	started bool // a submit was written, so Wait has a batch to finish
+	d       *dispatcher
+	wg      sync.WaitGroup // one per submit awaiting its response
+
+	mu  sync.Mutex
+	err error // the transport error that ended the batch early
+}
+
+// SubmitFuture is the eventual result of a pipelined submit
+type SubmitFuture struct {
+	msg       *SMSMessage
+	destAddr  string
+	messageID string
+	err       error
+}
+
+// Result returns the message ID or error of the submit. It is only valid
+// after the pipeline's Wait has returned.
+func (f *SubmitFuture) Result() (string, error) {
+	return f.messageID, f.err
+}
+
+// Pipeline starts a new pipelined batch of submits
+func (c *Client) Pipeline() *Pipeline {
+	return &Pipeline{c: c}
+}
+
+// Submit encodes and writes msg immediately and returns a future for its
+// response. Validation and write errors are recorded in the future; after
+// a transport error the remaining submits fail without being sent.
+func (p *Pipeline) Submit(msg *SMSMessage) *SubmitFuture {
+	f := &SubmitFuture{msg: msg}
+	if err := p.failed(); err != nil {
+		p.c.drain.record(false)
+		f.err = err
+		return f
+	}
+
+	pdu, destAddr, err := p.c.newMessagePDU(SUBMIT_SM, msg)
+	if err != nil {
+		f.err = err
+		return f
+	}
+	f.destAddr = destAddr
+
+	if p.c.destLimiter != nil && !p.c.destLimiter.allow(destAddr, p.c.clock.Now()) {
+		f.err = ErrDestinationThrottled
+		return f
+	}
+
+	p.c.backpressure.wait(p.c.clock)
+	if p.c.limiter != nil {
+		p.c.limiter.wait(p.c.clock)
+	}
+	if msg.expired(p.c.clock.Now()) {
+		f.err = ErrDeadlineExpired
+		return f
+	}
+
+	if !p.started {
+		if !p.c.drain.enter() {
+			f.err = ErrShuttingDown
+			return f
+		}
+		p.started = true
+		p.c.startReadLoop()
+		if p.d = p.c.dispatch.Load(); p.d == nil {
+			p.fail(errors.New("not connected"))
+		}
+	} else if p.c.drain.aborted() {
+		p.c.drain.record(false)
+		f.err = ErrShuttingDown
+		return f
+	}
+	if err := p.failed(); err != nil {
+		p.c.drain.record(false)
+		f.err = err
+		return f
+	}
+
+	// Take a window slot, given back once the response is in
+	select {
+	case p.c.window <- struct{}{}:
+	case <-p.d.done:
+		p.c.drain.record(false)
+		p.fail(p.d.err)
+		f.err = p.d.err
+		return f
+	}
+
+	resp, err := p.d.register(pdu.sequenceNumber)
+	if err != nil {
+		<-p.c.window
+		p.c.drain.record(false)
+		f.err = err
+		return f
+	}
+	p.c.mu.Lock()
+	err = p.c.write(pdu)
+	p.c.mu.Unlock()
+	if err != nil {
+		p.d.cancel(pdu.sequenceNumber)
+		<-p.c.window
+		p.c.drain.record(false)
+		p.fail(err)
+		f.err = err
+		return f
+	}
+
+	p.wg.Add(1)
+	go p.await(f, pdu.sequenceNumber, resp)
+	return f
+}
+
+// await completes f when its response arrives, allowing the read timeout
+func (p *Pipeline) await(f *SubmitFuture, seq uint32, resp chan *pdu) {
+	defer p.wg.Done()
+	defer func() { <-p.c.window }()
+
+	select {
+	case r := <-resp:
+		p.c.drain.record(true)
+		f.messageID, f.err = p.c.submitResult(f.msg, f.destAddr, r)
+	case <-p.d.done:
+		p.c.drain.record(false)
+		p.fail(p.d.err)
+		f.err = errors.Join(errors.New("no response received"), p.d.err)
+	case <-p.c.clock.After(p.c.conn.readTimeout):
+		p.d.cancel(seq)
+		p.c.drain.record(false)
+		err := fmt.Errorf("no submit_sm_resp received: %w", os.ErrDeadlineExceeded)
+		p.fail(err)
+		f.err = err
+	}
+}
+
+// fail records the first transport error, which ends the batch
+func (p *Pipeline) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// failed returns the error that ended the batch, if any
+func (p *Pipeline) failed() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Wait waits for the responses to all submitted messages and completes
+// their futures. It returns the transport error that ended the batch
+// early, if any; per-message failures are only reported through the
+// futures.
+func (p *Pipeline) Wait() error {
+	if !p.started {
+		return p.failed()
+	}
+
+	p.wg.Wait()
+	p.started = false
+	p.c.drain.leave()
+
+	err := p.failed()
+	if err != nil && isConnectionLost(err) {
+		p.c.connectionLost(err)
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+package smpp
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// delayedSubmits returns an SMSC handler answering each submit_sm after
+// delay, without holding up the submits behind it, as over a link with
+// that round-trip time
+func delayedSubmits(delay time.Duration) func(*smscConn, *pdu) bool {
+	return func(conn *smscConn, p *pdu) bool {
+		if p.commandID != SUBMIT_SM {
+			return false
+		}
+		time.AfterFunc(delay, func() { defaultReply(conn, p) })
+		return true
+	}
+}
+
+func batchOf(n int) []*SMSMessage {
+	msgs := make([]*SMSMessage, n)
+	for i := range msgs {
+		msgs[i] = &SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: fmt.Appendf(nil, "message %d", i)}
+	}
+	return msgs
+}
+
+func TestPipelineWindow(t *testing.T) {
+	var outstanding, peak atomic.Int32
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID != SUBMIT_SM {
+			return false
+		}
+		n := outstanding.Add(1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.AfterFunc(10*time.Millisecond, func() {
+			outstanding.Add(-1)
+			defaultReply(conn, p)
+		})
+		return true
+	})
+	c := smsc.client(WithWindow(3))
+	connect(t, c)
+
+	results, err := c.SendBatch(batchOf(12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("message %d: %v", i, r.Err)
+		}
+	}
+	if peak.Load() > 3 {
+		t.Fatalf("%d submits outstanding at once, window is 3", peak.Load())
+	}
+}
+
+func TestPipelineAnswersSMSCDuringBatch(t *testing.T) {
+	linkResp := make(chan struct{}, 1)
+	var once sync.Once
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		switch p.commandID {
+		case ENQUIRE_LINK_RESP:
+			linkResp <- struct{}{}
+			return true
+		case SUBMIT_SM:
+			// Hold the first response until the client has answered an
+			// enquire_link sent in the middle of the batch
+			first := false
+			once.Do(func() { first = true })
+			if !first {
+				return false
+			}
+			conn.send(ENQUIRE_LINK, ESME_ROK, 1000, nil)
+			go func() {
+				select {
+				case <-linkResp:
+					defaultReply(conn, p)
+				case <-time.After(time.Second):
+				}
+			}()
+			return true
+		}
+		return false
+	})
+	c := smsc.client()
+	connect(t, c)
+
+	results, err := c.SendBatch(batchOf(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil {
+		t.Fatalf("first submit: %v", results[0].Err)
+	}
+}
+
+func TestPipelineThrottlingSlowsBatch(t *testing.T) {
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID == SUBMIT_SM {
+			conn.reply(p, ESME_RTHROTTLED, nil)
+			return true
+		}
+		return false
+	})
+	c := smsc.client(WithRateLimit(100))
+	connect(t, c)
+
+	p := c.Pipeline()
+	p.Submit(&SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+
+	// The throttled response reaches the limiter before Wait is called
+	waitFor(t, "the rate limiter to slow down", func() bool { return c.EffectiveTPS() < 100 })
+	p.Wait()
+}
+
+// benchmarkHighLatency sends batches of 50 messages to an SMSC answering
+// each after 5ms, the round-trip time of a distant link
+func benchmarkHighLatency(b *testing.B, send func(c *Client, msgs []*SMSMessage) error) {
+	smsc := newTestSMSC(b, delayedSubmits(5*time.Millisecond))
+	c := smsc.client(WithWindow(50))
+	if err := c.Connect(false); err != nil {
+		b.Fatal(err)
+	}
+	defer c.Disconnect()
+
+	msgs := batchOf(50)
+	b.ResetTimer()
+	for b.Loop() {
+		if err := send(c, msgs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSendSMSHighLatency(b *testing.B) {
+	benchmarkHighLatency(b, func(c *Client, msgs []*SMSMessage) error {
+		for _, msg := range msgs {
+			if _, err := c.SendSMS(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkPipelineHighLatency(b *testing.B) {
+	benchmarkHighLatency(b, func(c *Client, msgs []*SMSMessage) error {
+		_, err := c.SendBatch(msgs)
+		return err
+	})
+}
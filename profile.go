@@ -0,0 +1,98 @@
+package smpp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Built-in validation profiles
+const (
+	ProfileGeneric34          = "generic-3.4"
+	ProfileAlphanumericSender = "alphanumeric-sender"
+)
+
+// Profile checks an outgoing message against operator-specific rules
+type Profile interface {
+	Validate(msg *SMSMessage) error
+}
+
+// ProfileFunc adapts a function to the Profile interface
+type ProfileFunc func(msg *SMSMessage) error
+
+// Validate calls f(msg)
+func (f ProfileFunc) Validate(msg *SMSMessage) error {
+	return f(msg)
+}
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]Profile{
+		ProfileGeneric34:          ProfileFunc(validateGeneric34),
+		ProfileAlphanumericSender: ProfileFunc(validateAlphanumericSender),
+	}
+)
+
+// RegisterProfile makes a profile available under name, replacing any
+// profile previously registered with the same name
+func RegisterProfile(name string, p Profile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[name] = p
+}
+
+// lookupProfile returns the profile registered under name
+func lookupProfile(name string) (Profile, error) {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	p, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown validation profile %q", name)
+	}
+	return p, nil
+}
+
+// validateGeneric34 enforces the field limits of SMPP 3.4 submit_sm
+func validateGeneric34(msg *SMSMessage) error {
+	if len(msg.ServiceType) >= maxServiceTypeLength {
+		return fmt.Errorf("service_type %q exceeds %d characters", msg.ServiceType, maxServiceTypeLength-1)
+	}
+	if len(msg.SourceAddr) >= maxAddressLength {
+		return fmt.Errorf("source_addr %q exceeds %d characters", msg.SourceAddr, maxAddressLength-1)
+	}
+	if msg.DestAddr == "" {
+		return errors.New("destination_addr is empty")
+	}
+	if len(msg.DestAddr) >= maxAddressLength {
+		return fmt.Errorf("destination_addr %q exceeds %d characters", msg.DestAddr, maxAddressLength-1)
+	}
+	if msg.RegisteredDelivery.Receipt > ReceiptFailure {
+		return fmt.Errorf("registered_delivery receipt value %d is reserved", msg.RegisteredDelivery.Receipt)
+	}
+	return nil
+}
+
+// validateAlphanumericSender additionally requires an alphanumeric sender
+// ID of at most 11 characters containing at least one letter
+func validateAlphanumericSender(msg *SMSMessage) error {
+	if err := validateGeneric34(msg); err != nil {
+		return err
+	}
+	if msg.SourceAddr == "" || len(msg.SourceAddr) > 11 {
+		return fmt.Errorf("alphanumeric source_addr %q must be 1-11 characters", msg.SourceAddr)
+	}
+	hasLetter := false
+	for _, r := range msg.SourceAddr {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+			hasLetter = true
+		case r >= '0' && r <= '9', r == ' ':
+		default:
+			return fmt.Errorf("alphanumeric source_addr %q contains invalid character %q", msg.SourceAddr, r)
+		}
+	}
+	if !hasLetter {
+		return fmt.Errorf("alphanumeric source_addr %q contains no letters", msg.SourceAddr)
+	}
+	return nil
+}
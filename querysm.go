@@ -0,0 +1,100 @@
+package smpp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryResult is the status of a message as reported in query_sm_resp
+type QueryResult struct {
+	MessageID string
+	FinalDate time.Time // when the message reached a final state; zero until then
+	State     MessageState
+	ErrorCode byte // network error code, zero if none
+}
+
+// QuerySM asks the SMSC for the status of a message submitted earlier,
+// for polling when delivery receipts are not available. sourceAddr must
+// be the source_addr the message was submitted with.
+func (c *Client) QuerySM(messageID, sourceAddr string) (*QueryResult, error) {
+	if c.State() != StateBound {
+		return nil, ErrNotBound
+	}
+	if !c.bindMode.canSubmit() {
+		return nil, errors.New("a receiver session cannot query messages")
+	}
+
+	pdu := newPDU(QUERY_SM, c.nextSequence())
+	if err := pdu.writeCString("message_id", c.smscMessageID(messageID), maxMessageIDLength); err != nil {
+		return nil, err
+	}
+	sourceAddr, err := c.fitAddress("source_addr", sourceAddr)
+	if err != nil {
+		return nil, err
+	}
+	ton, npi := c.sourceAddressing(&SMSMessage{SourceAddr: sourceAddr})
+	pdu.writeByte(ton) // source_addr_ton
+	pdu.writeByte(npi) // source_addr_npi
+	pdu.writeString(sourceAddr)
+
+	resp, err := c.sendPDU(pdu)
+	if err != nil {
+		return nil, fmt.Errorf("query_sm: %w", err)
+	}
+	if resp.commandStatus != ESME_ROK {
+		return nil, fmt.Errorf("query_sm: %w", &StatusError{resp.commandStatus})
+	}
+	result, err := parseQuerySMResp(resp.body)
+	if err != nil {
+		return nil, fmt.Errorf("query_sm_resp: %w", err)
+	}
+	result.MessageID = messageID
+	return result, nil
+}
+
+// parseQuerySMResp decodes the body of a query_sm_resp
+func parseQuerySMResp(body []byte) (*QueryResult, error) {
+	r := &bodyReader{b: body}
+	result := &QueryResult{}
+	var err error
+	if result.MessageID, err = r.cString("message_id", maxMessageIDLength); err != nil {
+		return nil, err
+	}
+	finalDate, err := r.cString("final_date", maxTimeLength)
+	if err != nil {
+		return nil, err
+	}
+	if finalDate != "" {
+		if result.FinalDate, _, err = ParseSMPPTime(finalDate); err != nil {
+			return nil, fmt.Errorf("final_date: %w", err)
+		}
+	}
+	state, err := r.octet("message_state")
+	if err != nil {
+		return nil, err
+	}
+	result.State = MessageState(state)
+	if result.ErrorCode, err = r.octet("error_code"); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// smscMessageID undoes QuirkHexMessageIDs, returning a message ID in the
+// form the SMSC issued it
+func (c *Client) smscMessageID(messageID string) string {
+	if c.hexIDs == nil {
+		return messageID
+	}
+	if original, ok := c.hexIDs.get(messageID); ok {
+		return original
+	}
+	v, err := strconv.ParseUint(messageID, 10, 64)
+	if err != nil {
+		return messageID
+	}
+	return strings.ToUpper(strconv.FormatUint(v, 16))
+}
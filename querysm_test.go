@@ -0,0 +1,108 @@
+package smpp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuerySM(t *testing.T) {
+	type query struct {
+		messageID string
+		source    Address
+	}
+	queries := make(chan query, 1)
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID != QUERY_SM {
+			return false
+		}
+		r := &bodyReader{b: p.body}
+		var q query
+		q.messageID, _ = r.cString("message_id", maxMessageIDLength)
+		q.source.TON, _ = r.octet("source_addr_ton")
+		q.source.NPI, _ = r.octet("source_addr_npi")
+		q.source.Addr, _ = r.cString("source_addr", maxAddressLength)
+		queries <- q
+
+		resp := newPDU(QUERY_SM_RESP, 0)
+		resp.writeString(q.messageID)
+		resp.writeString("241016120130000+") // final_date
+		resp.writeByte(byte(MessageStateUndeliverable))
+		resp.writeByte(0x45) // error_code
+		conn.reply(p, ESME_ROK, resp.body)
+		return true
+	})
+	c := smsc.client(WithQuirks(QuirkHexMessageIDs))
+	connect(t, c)
+
+	// The decimal ID the client reported goes back to the SMSC in hex
+	result, err := c.QuerySM("26", "Shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := <-queries; q.messageID != "1A" || q.source.Addr != "Shop" {
+		t.Fatalf("query_sm for %q from %+v", q.messageID, q.source)
+	}
+	want := time.Date(2024, 10, 16, 12, 1, 30, 0, time.UTC)
+	if result.MessageID != "26" || !result.FinalDate.Equal(want) || result.State != MessageStateUndeliverable || result.ErrorCode != 0x45 {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestHexMessageIDKeepsOriginalForm(t *testing.T) {
+	queried := make(chan string, 2)
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		switch p.commandID {
+		case SUBMIT_SM:
+			conn.reply(p, ESME_ROK, []byte("0a1b\x00"))
+		case QUERY_SM, REPLACE_SM:
+			id, _ := (&bodyReader{b: p.body}).cString("message_id", maxMessageIDLength)
+			queried <- id
+			defaultReply(conn, p)
+		default:
+			return false
+		}
+		return true
+	})
+	c := smsc.client(WithQuirks(QuirkHexMessageIDs))
+	connect(t, c)
+
+	msg := &SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")}
+	id, err := c.SendSMS(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "2587" {
+		t.Fatalf("message ID = %q, want 2587", id)
+	}
+
+	// Both go back to the SMSC zero-padded and in lower case, as issued
+	c.QuerySM(id, "Shop")
+	if got := <-queried; got != "0a1b" {
+		t.Fatalf("query_sm for %q, want 0a1b", got)
+	}
+	if err := c.ReplaceSM(id, msg); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-queried; got != "0a1b" {
+		t.Fatalf("replace_sm for %q, want 0a1b", got)
+	}
+}
+
+func TestQuerySMStatusError(t *testing.T) {
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID == QUERY_SM {
+			conn.reply(p, ESME_RQUERYFAIL, nil)
+			return true
+		}
+		return false
+	})
+	c := smsc.client()
+	connect(t, c)
+
+	_, err := c.QuerySM("abc", "Shop")
+	var se *StatusError
+	if !errors.As(err, &se) || se.Status != ESME_RQUERYFAIL {
+		t.Fatalf("err = %v, want ESME_RQUERYFAIL", err)
+	}
+}
@@ -0,0 +1,60 @@
+package smpp
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Quirks enables workarounds for known SMSC interoperability bugs. Combine
+// flags with |.
+type Quirks uint32
+
+const (
+	// QuirkMessageIDNoNull takes the whole submit_sm_resp body as the
+	// message ID, for SMSCs that leave out the NULL terminator and pad or
+	// append junk instead of optional parameters. Trailing NULLs are
+	// trimmed.
+	QuirkMessageIDNoNull Quirks = 1 << iota
+
+	// QuirkEmptyServiceType always sends an empty service_type, for SMSCs
+	// that reject any other value.
+	QuirkEmptyServiceType
+
+	// QuirkHexMessageIDs converts message IDs in submit_sm_resp from
+	// hexadecimal to decimal, for SMSCs that report them in decimal in
+	// delivery receipts. IDs that are not valid hex are left alone.
+	// QuerySM and ReplaceSM send the SMSC the ID exactly as it issued it,
+	// for the last hexMessageIDs submits; older IDs are converted back in
+	// upper case without leading zeros.
+	QuirkHexMessageIDs
+)
+
+// hexMessageIDs is how many message IDs issued under QuirkHexMessageIDs
+// are remembered in their original form
+const hexMessageIDs = 10000
+
+// has reports whether all flags in q are set
+func (q Quirks) has(flag Quirks) bool {
+	return q&flag == flag
+}
+
+// parseSubmitResp extracts the message ID and optional parameters of a
+// submit_sm_resp body, applying the enabled quirks
+func (c *Client) parseSubmitResp(body []byte) (string, map[uint16][]byte) {
+	var messageID string
+	var tlvs map[uint16][]byte
+	if c.quirks.has(QuirkMessageIDNoNull) {
+		messageID = string(bytes.TrimRight(body, "\x00"))
+	} else {
+		messageID, tlvs = submitRespFields(body)
+	}
+
+	if c.quirks.has(QuirkHexMessageIDs) && messageID != "" {
+		if v, err := strconv.ParseUint(messageID, 16, 64); err == nil {
+			decimal := strconv.FormatUint(v, 10)
+			c.hexIDs.put(decimal, messageID)
+			messageID = decimal
+		}
+	}
+	return messageID, tlvs
+}
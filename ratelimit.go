@@ -0,0 +1,79 @@
+package smpp
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket whose rate adapts to SMSC feedback: each
+// ESME_RTHROTTLED halves the effective rate and each accepted submit raises
+// it by 1% of the configured ceiling, so it settles near the SMSC's real
+// limit.
+type rateLimiter struct {
+	mu     sync.Mutex
+	maxTPS float64
+	tps    float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(tps float64) *rateLimiter {
+	return &rateLimiter{
+		maxTPS: tps,
+		tps:    tps,
+		tokens: max(1, tps),
+	}
+}
+
+// wait blocks until a token is available and takes it
+func (l *rateLimiter) wait(clock Clock) {
+	for {
+		l.mu.Lock()
+		now := clock.Now()
+		if l.last.IsZero() {
+			l.last = now
+		}
+		l.tokens = min(max(1, l.tps), l.tokens+now.Sub(l.last).Seconds()*l.tps)
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return
+		}
+		delay := time.Duration((1 - l.tokens) / l.tps * float64(time.Second))
+		l.mu.Unlock()
+		clock.Sleep(delay)
+	}
+}
+
+// throttled halves the effective rate, down to 1% of the ceiling
+func (l *rateLimiter) throttled() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tps = max(l.tps/2, l.maxTPS/100)
+}
+
+// accepted raises the effective rate back toward the ceiling
+func (l *rateLimiter) accepted() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tps = min(l.tps+l.maxTPS/100, l.maxTPS)
+}
+
+// reset empties the bucket after a (re)bind so a backlog cannot burst out
+// at once, and restarts the effective rate at fraction of the ceiling
+// (at least 1%) to ramp up from there
+func (l *rateLimiter) reset(fraction float64, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tps = max(l.maxTPS*fraction, l.maxTPS/100)
+	l.tokens = min(l.tokens, 1)
+	l.last = now
+}
+
+// rate returns the current effective rate
+func (l *rateLimiter) rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tps
+}
@@ -0,0 +1,144 @@
+package smpp
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MessageState is the message_state of a delivery receipt
+type MessageState byte
+
+const (
+	MessageStateEnroute       MessageState = 1
+	MessageStateDelivered     MessageState = 2
+	MessageStateExpired       MessageState = 3
+	MessageStateDeleted       MessageState = 4
+	MessageStateUndeliverable MessageState = 5
+	MessageStateAccepted      MessageState = 6
+	MessageStateUnknown       MessageState = 7
+	MessageStateRejected      MessageState = 8
+)
+
+// DeliveryReceipt is a delivery receipt decoded from the conventional
+// "id:... sub:... dlvrd:... submit date:... done date:... stat:... err:...
+// text:..." short_message (SMPP 3.4 Appendix B)
+type DeliveryReceipt struct {
+	MessageID  string
+	Submitted  int // sub: messages originally submitted
+	Delivered  int // dlvrd: messages delivered
+	SubmitDate time.Time
+	DoneDate   time.Time
+	Stat       string // DELIVRD, EXPIRED, DELETED, UNDELIV, ACCEPTD, UNKNOWN, REJECTD, ...
+	Err        string // network specific error code
+	Text       string // start of the original message
+
+	// State is the message_state TLV, zero when absent
+	State MessageState
+
+	// Intermediate marks an intermediate notification rather than the
+	// final outcome; see RegisteredDelivery.Intermediate
+	Intermediate bool
+}
+
+// receiptDateLayouts are the date formats seen in receipts, YYMMDDhhmm as
+// in the specification or with seconds appended
+var receiptDateLayouts = map[int]string{
+	10: "0601021504",
+	12: "060102150405",
+}
+
+// ParseDeliveryReceipt parses the text of a delivery receipt. Keys are
+// matched case-insensitively and may come in any order; text runs to the
+// end. Only id is required. Dates carry no zone and are returned in UTC;
+// fields that do not parse are left zero.
+func ParseDeliveryReceipt(s string) (*DeliveryReceipt, error) {
+	r := &DeliveryReceipt{}
+	head := s
+	if i := strings.Index(strings.ToLower(s), "text:"); i >= 0 {
+		head, r.Text = s[:i], s[i+len("text:"):]
+	}
+	// Join the two-word keys so the rest splits on spaces
+	head = strings.NewReplacer("submit date:", "submit_date:", "Submit date:", "submit_date:",
+		"done date:", "done_date:", "Done date:", "done_date:").Replace(head)
+
+	found := false
+	for _, field := range strings.Fields(head) {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "id":
+			r.MessageID, found = value, true
+		case "sub":
+			r.Submitted, _ = strconv.Atoi(value)
+		case "dlvrd":
+			r.Delivered, _ = strconv.Atoi(value)
+		case "submit_date":
+			r.SubmitDate = parseReceiptDate(value)
+		case "done_date":
+			r.DoneDate = parseReceiptDate(value)
+		case "stat":
+			r.Stat = value
+		case "err":
+			r.Err = value
+		}
+	}
+	if !found {
+		return nil, errors.New("delivery receipt has no id")
+	}
+	return r, nil
+}
+
+// parseReceiptDate parses a receipt date, returning the zero time if it
+// is malformed
+func parseReceiptDate(s string) time.Time {
+	layout, ok := receiptDateLayouts[len(s)]
+	if !ok {
+		return time.Time{}
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// IsDeliveryReceipt reports whether d carries an SMSC delivery receipt or
+// intermediate notification rather than a mobile-originated message. The
+// receipted_message_id TLV marks a receipt too, for SMSCs that leave the
+// esm_class message type unset.
+func (d *DeliverSM) IsDeliveryReceipt() bool {
+	if _, ok := d.TLVs[TLV_RECEIPTED_MESSAGE_ID]; ok {
+		return true
+	}
+	return d.EsmClass.Type == MessageTypeDeliveryReceipt || d.EsmClass.Type == MessageTypeIntermediate
+}
+
+// DeliveryReceipt parses the receipt carried by d. The receipted_message_id
+// and message_state TLVs take precedence over the text, as they are
+// unambiguous where present.
+func (d *DeliverSM) DeliveryReceipt() (*DeliveryReceipt, error) {
+	if !d.IsDeliveryReceipt() {
+		return nil, errors.New("deliver_sm is not a delivery receipt")
+	}
+	text, _ := d.Text()
+	r, err := ParseDeliveryReceipt(text)
+	id, hasID := d.TLVs[TLV_RECEIPTED_MESSAGE_ID]
+	if err != nil && !hasID {
+		return nil, err
+	}
+	if r == nil {
+		r = &DeliveryReceipt{}
+	}
+	if hasID {
+		r.MessageID = strings.TrimRight(string(id), "\x00")
+	}
+	if v, ok := d.TLVs[TLV_MESSAGE_STATE]; ok && len(v) == 1 {
+		r.State = MessageState(v[0])
+	}
+	r.Intermediate = d.EsmClass.Type == MessageTypeIntermediate
+	return r, nil
+}
@@ -0,0 +1,49 @@
+package smpp
+
+import "testing"
+
+func TestReceiptMarkedOnlyByTLV(t *testing.T) {
+	body := deliverSMBody("998901234567", "Shop", byte(MessageTypeDefault), "",
+		tlv(TLV_RECEIPTED_MESSAGE_ID, []byte("abc123\x00")),
+		tlv(TLV_MESSAGE_STATE, []byte{byte(MessageStateDelivered)}))
+	d, err := parseDeliverSM(1, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.IsDeliveryReceipt() {
+		t.Fatal("receipted_message_id did not mark a receipt")
+	}
+	r, err := d.DeliveryReceipt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.MessageID != "abc123" || r.State != MessageStateDelivered {
+		t.Fatalf("receipt = %+v", r)
+	}
+}
+
+func TestMobileOriginatedIsNotReceipt(t *testing.T) {
+	d, err := parseDeliverSM(1, deliverSMBody("998901234567", "Shop", 0, "id:1 stat:DELIVRD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.IsDeliveryReceipt() {
+		t.Fatal("mobile-originated message taken for a receipt")
+	}
+}
+
+func TestParseDeliveryReceipt(t *testing.T) {
+	r, err := ParseDeliveryReceipt("id:42 sub:001 dlvrd:001 submit date:2410161200 done date:241016120130 stat:DELIVRD err:000 text:Hello there")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.MessageID != "42" || r.Submitted != 1 || r.Delivered != 1 || r.Stat != "DELIVRD" || r.Err != "000" || r.Text != "Hello there" {
+		t.Fatalf("receipt = %+v", r)
+	}
+	if r.DoneDate.Second() != 30 || r.SubmitDate.Hour() != 12 {
+		t.Fatalf("dates = %v, %v", r.SubmitDate, r.DoneDate)
+	}
+	if _, err := ParseDeliveryReceipt("stat:DELIVRD"); err == nil {
+		t.Fatal("parsed a receipt without an id")
+	}
+}
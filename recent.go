@@ -0,0 +1,79 @@
+package smpp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RecentPDU is the header of a PDU kept by WithRecentPDUs
+type RecentPDU struct {
+	Time      time.Time
+	Outbound  bool // sent by the client rather than received
+	CommandID uint32
+	Status    uint32
+	Sequence  uint32
+}
+
+// String renders the entry on one line, for dumping after an error
+func (p RecentPDU) String() string {
+	dir := "<-"
+	if p.Outbound {
+		dir = "->"
+	}
+	return fmt.Sprintf("%s %s %s seq=%d status=0x%08X",
+		p.Time.Format(time.RFC3339Nano), dir, commandName(p.CommandID), p.Sequence, p.Status)
+}
+
+// pduRing keeps the most recent PDU headers in a fixed-size ring
+type pduRing struct {
+	mu      sync.Mutex
+	entries []RecentPDU
+	next    int
+	full    bool
+}
+
+func newPDURing(size int) *pduRing {
+	return &pduRing{entries: make([]RecentPDU, size)}
+}
+
+// add records p's header, overwriting the oldest entry when full
+func (r *pduRing) add(outbound bool, p *pdu) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = RecentPDU{
+		Time:      time.Now(),
+		Outbound:  outbound,
+		CommandID: p.commandID,
+		Status:    p.commandStatus,
+		Sequence:  p.sequenceNumber,
+	}
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot returns a copy of the entries, oldest first
+func (r *pduRing) snapshot() []RecentPDU {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]RecentPDU(nil), r.entries[:r.next]...)
+	}
+	out := make([]RecentPDU, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	return append(out, r.entries[:r.next]...)
+}
+
+// RecentPDUs returns the headers of the last PDUs sent and received, oldest
+// first, as kept by WithRecentPDUs. It returns nil when that is not set.
+func (c *Client) RecentPDUs() []RecentPDU {
+	if c.conn.recent == nil {
+		return nil
+	}
+	return c.conn.recent.snapshot()
+}
@@ -0,0 +1,80 @@
+package smpp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Session recordings are a sequence of records, each a 13-byte header
+// followed by the PDU exactly as it crossed the wire:
+//
+//	direction  1 byte   'O' sent by the client, 'I' received from the SMSC
+//	timestamp  8 bytes  Unix time in nanoseconds, big-endian
+//	length     4 bytes  PDU length, big-endian
+const recordHeaderLength = 13
+
+const (
+	recordOutbound byte = 'O'
+	recordInbound  byte = 'I'
+)
+
+// SessionRecord is one PDU read back from a session recording
+type SessionRecord struct {
+	Time     time.Time
+	Outbound bool
+	PDU      []byte // header and body
+}
+
+// recorder appends PDUs to a session recording
+type recorder struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// record writes one PDU. Write errors are ignored so a failing recording
+// never disturbs the session.
+func (r *recorder) record(direction byte, raw []byte) {
+	if r == nil {
+		return
+	}
+	hdr := make([]byte, recordHeaderLength, recordHeaderLength+len(raw))
+	hdr[0] = direction
+	binary.BigEndian.PutUint64(hdr[1:9], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint32(hdr[9:13], uint32(len(raw)))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w.Write(append(hdr, raw...))
+}
+
+// ReadSessionRecord reads the next record of a recording made with
+// WithSessionRecorder. It returns io.EOF at the clean end of the stream.
+func ReadSessionRecord(r io.Reader) (SessionRecord, error) {
+	var hdr [recordHeaderLength]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return SessionRecord{}, err
+	}
+	if hdr[0] != recordOutbound && hdr[0] != recordInbound {
+		return SessionRecord{}, fmt.Errorf("bad record direction %q", hdr[0])
+	}
+	n := binary.BigEndian.Uint32(hdr[9:13])
+	if n > maxPDULength {
+		return SessionRecord{}, fmt.Errorf("record length %d too large", n)
+	}
+	raw := make([]byte, n)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return SessionRecord{}, err
+	}
+	return SessionRecord{
+		Time:     time.Unix(0, int64(binary.BigEndian.Uint64(hdr[1:9]))),
+		Outbound: hdr[0] == recordOutbound,
+		PDU:      raw,
+	}, nil
+}
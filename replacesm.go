@@ -0,0 +1,53 @@
+package smpp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ReplaceSM replaces a message submitted earlier that the SMSC has not yet
+// delivered. msg.SourceAddr must match the original submission; its
+// ScheduleDeliveryTime, ValidityPeriod, RegisteredDelivery and Message
+// replace the original fields, with zero values selecting the SMSC
+// defaults. replace_sm carries no data_coding, so Message must be encoded
+// like the original and fit in a single short_message.
+func (c *Client) ReplaceSM(messageID string, msg *SMSMessage) error {
+	if c.State() != StateBound {
+		return ErrNotBound
+	}
+	if !c.bindMode.canSubmit() {
+		return errors.New("a receiver session cannot replace messages")
+	}
+	if len(msg.Message) > c.maxShortMessageOctets {
+		return fmt.Errorf("message too long (%d bytes), max is %d bytes", len(msg.Message), c.maxShortMessageOctets)
+	}
+
+	pdu := newPDU(REPLACE_SM, c.nextSequence())
+	if err := pdu.writeCString("message_id", c.smscMessageID(messageID), maxMessageIDLength); err != nil {
+		return err
+	}
+	sourceAddr, err := c.fitAddress("source_addr", msg.SourceAddr)
+	if err != nil {
+		return err
+	}
+	ton, npi := c.sourceAddressing(msg)
+	pdu.writeByte(ton) // source_addr_ton
+	pdu.writeByte(npi) // source_addr_npi
+	pdu.writeString(sourceAddr)
+	schedule, validity := msg.deliveryTimes()
+	pdu.writeString(schedule)               // schedule_delivery_time
+	pdu.writeString(validity)               // validity_period
+	pdu.writeByte(msg.registeredDelivery()) // registered_delivery
+	pdu.writeByte(0)                        // sm_default_msg_id
+	pdu.writeByte(byte(len(msg.Message)))   // sm_length
+	pdu.write(msg.Message)                  // short_message
+
+	resp, err := c.sendPDU(pdu)
+	if err != nil {
+		return fmt.Errorf("replace_sm: %w", err)
+	}
+	if resp.commandStatus != ESME_ROK {
+		return fmt.Errorf("replace_sm: %w", &StatusError{resp.commandStatus})
+	}
+	return nil
+}
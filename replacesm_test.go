@@ -0,0 +1,63 @@
+package smpp
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestReplaceSM(t *testing.T) {
+	type replace struct {
+		messageID, source, schedule, validity string
+		registered                            byte
+		message                               []byte
+	}
+	replaces := make(chan replace, 1)
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID != REPLACE_SM {
+			return false
+		}
+		r := &bodyReader{b: p.body}
+		var rp replace
+		rp.messageID, _ = r.cString("message_id", maxMessageIDLength)
+		r.octets("source_addr_ton and npi", 2)
+		rp.source, _ = r.cString("source_addr", maxAddressLength)
+		rp.schedule, _ = r.cString("schedule_delivery_time", maxTimeLength)
+		rp.validity, _ = r.cString("validity_period", maxTimeLength)
+		rp.registered, _ = r.octet("registered_delivery")
+		r.octet("sm_default_msg_id")
+		n, _ := r.octet("sm_length")
+		rp.message, _ = r.octets("short_message", int(n))
+		replaces <- rp
+		return false // the default reply acknowledges replace_sm
+	})
+	c := smsc.client()
+	connect(t, c)
+
+	err := c.ReplaceSM("abc", &SMSMessage{
+		SourceAddr:            "Shop",
+		Message:               []byte("new text"),
+		ValidityPeriod:        90 * time.Minute,
+		RequestDeliveryReport: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp := <-replaces
+	if rp.messageID != "abc" || rp.source != "Shop" || rp.schedule != "" || rp.registered != 1 {
+		t.Fatalf("replace_sm = %+v", rp)
+	}
+	if rp.validity != FormatSMPPDuration(90*time.Minute) || !bytes.Equal(rp.message, []byte("new text")) {
+		t.Fatalf("validity %q, message %q", rp.validity, rp.message)
+	}
+}
+
+func TestReplaceSMTooLong(t *testing.T) {
+	smsc := newTestSMSC(t, nil)
+	c := smsc.client()
+	connect(t, c)
+
+	if err := c.ReplaceSM("abc", &SMSMessage{SourceAddr: "Shop", Message: make([]byte, 255)}); err == nil {
+		t.Fatal("replaced with a message longer than short_message allows")
+	}
+}
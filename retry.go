@@ -0,0 +1,126 @@
+package smpp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff sets the delays between connect attempts in ConnectWithRetry.
+// Zero fields take the defaults noted.
+type Backoff struct {
+	Initial    time.Duration // first delay, 1s
+	Max        time.Duration // longest delay, 1m
+	Multiplier float64       // growth per attempt, 2
+	Jitter     float64       // random spread as a fraction of the delay, 0-1
+
+	// AlreadyBound is the shortest wait after the SMSC answers the bind
+	// with ESME_RALYBND because it still holds the previous session,
+	// 5s by default
+	AlreadyBound time.Duration
+}
+
+// defaultAlreadyBoundWait gives an SMSC time to drop a stale session
+const defaultAlreadyBoundWait = 5 * time.Second
+
+// delay returns the wait after the given failed attempt, counting from 1
+func (b Backoff) delay(attempt int) time.Duration {
+	initial, limit, mult := b.Initial, b.Max, b.Multiplier
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if limit <= 0 {
+		limit = time.Minute
+	}
+	if mult < 1 {
+		mult = 2
+	}
+
+	d := float64(initial)
+	for i := 1; i < attempt && d < float64(limit); i++ {
+		d *= mult
+	}
+	d = min(d, float64(limit))
+	if j := min(max(b.Jitter, 0), 1); j > 0 {
+		d += d * j * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// ConnectWithRetry calls ConnectContext until it succeeds, waiting between
+// attempts as backoff describes, for services that start before the SMSC
+// is reachable or that recover from a lost connection. Every attempt binds
+// with the client's stored credentials. An ESME_RALYBND answer, common
+// when the SMSC has not yet noticed the old session died, is waited out
+// like any other failure but for at least backoff.AlreadyBound.
+//
+// The client is in StateReconnecting while it waits. If ctx ends first the
+// returned error wraps both ctx.Err() and the last attempt's error.
+func (c *Client) ConnectWithRetry(ctx context.Context, useTLS bool, backoff Backoff) error {
+	if s := c.State(); s != StateDisconnected {
+		return fmt.Errorf("smpp: cannot connect while %s", s)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := c.reconnects.do(ctx, func() error { return c.ConnectContext(ctx, useTLS) })
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			if errors.Is(err, ctx.Err()) {
+				return err // already says where ctx ended
+			}
+			return fmt.Errorf("%w; last attempt: %w", ctx.Err(), err)
+		}
+
+		delay := backoff.delay(attempt)
+		var se *StatusError
+		if errors.As(err, &se) && se.Status == ESME_RALYBND {
+			wait := backoff.AlreadyBound
+			if wait <= 0 {
+				wait = defaultAlreadyBoundWait
+			}
+			delay = max(delay, wait)
+		}
+		c.logger.Warn("smpp: connect failed, retrying", "attempt", attempt, "delay", delay, "error", err)
+		if c.state.transition(StateReconnecting) != nil {
+			return err // another caller connected meanwhile
+		}
+		select {
+		case <-c.clock.After(delay):
+		case <-ctx.Done():
+			c.state.transition(StateDisconnected)
+			return fmt.Errorf("%w; last attempt: %w", ctx.Err(), err)
+		}
+	}
+}
+
+// ReconnectLimiter bounds how many clients sharing it run a connect
+// attempt in ConnectWithRetry at once, so that when an SMSC restarts its
+// clients reconnect in a staggered way instead of all together. Share one
+// between clients with WithReconnectLimiter.
+type ReconnectLimiter struct {
+	slots chan struct{}
+}
+
+// NewReconnectLimiter allows k concurrent connect attempts (at least one)
+func NewReconnectLimiter(k int) *ReconnectLimiter {
+	return &ReconnectLimiter{slots: make(chan struct{}, max(k, 1))}
+}
+
+// do runs attempt once a slot is free, or returns ctx.Err() if ctx ends
+// first. A nil limiter runs attempt at once.
+func (l *ReconnectLimiter) do(ctx context.Context, attempt func() error) error {
+	if l == nil {
+		return attempt()
+	}
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-l.slots }()
+	return attempt()
+}
@@ -0,0 +1,114 @@
+package smpp
+
+import "bytes"
+
+// Concatenation UDH layout: UDHL, IEI 0x00 (8-bit reference), IEDL, ref,
+// total parts, part number
+const udhConcatLength = 6
+
+// Payload octets available per segment once the UDH is prepended
+const (
+	gsm7SegmentOctets   = 153 // 160 septets minus the UDH and fill bits
+	ucs2SegmentOctets   = 134 // 140 octets minus the UDH, even so UCS2 units stay whole
+	binarySegmentOctets = 134
+)
+
+// maxSegments is the largest part count a concatenation UDH can describe
+const maxSegments = 255
+
+// SegmentGSM7 splits unpacked GSM 03.38 septets into concatenated message
+// parts, each prefixed with a UDH carrying refNum. An escape (0x1B) is never
+// separated from the character it extends. It returns nil if data would
+// need more than 255 parts.
+func SegmentGSM7(data []byte, refNum byte) [][]byte {
+	return segment(data, refNum, gsm7SegmentOctets, gsm7Cut)
+}
+
+// SegmentUCS2 splits UCS2/UTF-16BE data into concatenated message parts,
+// each prefixed with a UDH carrying refNum. Surrogate pairs are never split.
+// It returns nil if data would need more than 255 parts.
+func SegmentUCS2(data []byte, refNum byte) [][]byte {
+	return segment(data, refNum, ucs2SegmentOctets, ucs2Cut)
+}
+
+// segment splits data into chunks of at most size octets, letting cut move
+// each boundary back, and prefixes every chunk with a concatenation UDH
+func segment(data []byte, refNum byte, size int, cut func(chunk []byte) int) [][]byte {
+	return addConcatUDH(split(data, size, cut), refNum)
+}
+
+// split cuts data into chunks of at most size octets. cut may move each
+// boundary back; a result of zero is ignored. It returns nil if size
+// leaves no room for content.
+func split(data []byte, size int, cut func(chunk []byte) int) [][]byte {
+	if size <= 0 {
+		return nil
+	}
+	var chunks [][]byte
+	for len(data) > 0 {
+		end := size
+		if end >= len(data) {
+			end = len(data)
+		} else if cut != nil {
+			if n := cut(data[:end]); n > 0 {
+				end = n
+			}
+		}
+		chunks = append(chunks, data[:end])
+		data = data[end:]
+	}
+	return chunks
+}
+
+// addConcatUDH prefixes each chunk with a concatenation UDH, returning nil
+// if there are none or more than a UDH can number
+func addConcatUDH(chunks [][]byte, refNum byte) [][]byte {
+	if len(chunks) == 0 || len(chunks) > maxSegments {
+		return nil
+	}
+
+	segments := make([][]byte, len(chunks))
+	for i, chunk := range chunks {
+		part := make([]byte, 0, udhConcatLength+len(chunk))
+		part = append(part, 0x05, 0x00, 0x03, refNum, byte(len(chunks)), byte(i+1))
+		segments[i] = append(part, chunk...)
+	}
+	return segments
+}
+
+// wordCut wraps cut so chunks end just after their last space character,
+// falling back to cut when a chunk holds a single word
+func wordCut(cut func(chunk []byte) int, space []byte) func(chunk []byte) int {
+	return func(chunk []byte) int {
+		end := len(chunk)
+		if cut != nil {
+			if n := cut(chunk); n > 0 {
+				end = n
+			}
+		}
+		for i := end - len(space); i > 0; i -= len(space) {
+			if bytes.Equal(chunk[i:i+len(space)], space) {
+				return i + len(space)
+			}
+		}
+		return end
+	}
+}
+
+// gsm7Cut keeps an escape septet together with the septet that follows it
+func gsm7Cut(chunk []byte) int {
+	end := len(chunk)
+	if chunk[end-1] == 0x1B {
+		end--
+	}
+	return end
+}
+
+// ucs2Cut keeps chunks on UTF-16 unit boundaries and surrogate pairs whole
+func ucs2Cut(chunk []byte) int {
+	end := len(chunk) &^ 1
+	if end >= 2 && chunk[end-2] >= 0xD8 && chunk[end-2] <= 0xDB {
+		end -= 2
+	}
+	return end
+}
@@ -0,0 +1,232 @@
+package smpp
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSplitRefusesEmptySize(t *testing.T) {
+	for _, size := range []int{0, -4} {
+		if chunks := split([]byte("hello"), size, nil); chunks != nil {
+			t.Errorf("split with size %d = %q, want nil", size, chunks)
+		}
+	}
+}
+
+func TestSegmentGSM7AndUCS2(t *testing.T) {
+	a := func(n int) []byte { return bytes.Repeat([]byte{'a'}, n) }
+	units := func(n int) []byte { return bytes.Repeat([]byte{0x04, 0x1F}, n) } // Cyrillic "Я"
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	euro := []byte{0x1B, 0x65}               // GSM 7-bit escape for "€"
+	smiley := []byte{0xD8, 0x3D, 0xDE, 0x00} // U+1F600 as a surrogate pair
+
+	for _, tt := range []struct {
+		name    string
+		segment func([]byte, byte) [][]byte
+		data    []byte
+		want    [][]byte // part contents after the UDH; nil for no parts
+	}{
+		{"GSM7 single part", SegmentGSM7, []byte("hello"), [][]byte{[]byte("hello")}},
+		{"GSM7 full part", SegmentGSM7, a(153), [][]byte{a(153)}},
+		{"GSM7 escape at boundary", SegmentGSM7, join(a(152), euro, []byte("b")),
+			[][]byte{a(152), join(euro, []byte("b"))}},
+		{"GSM7 escape ending a part", SegmentGSM7, join(a(151), euro, []byte("b")),
+			[][]byte{join(a(151), euro), []byte("b")}},
+		{"GSM7 255 parts", SegmentGSM7, a(153 * 255), slices.Repeat([][]byte{a(153)}, 255)},
+		{"GSM7 over 255 parts", SegmentGSM7, a(153*255 + 1), nil},
+		{"UCS2 single part", SegmentUCS2, units(3), [][]byte{units(3)}},
+		{"UCS2 surrogate pair at boundary", SegmentUCS2, join(units(66), smiley, units(1)),
+			[][]byte{units(66), join(smiley, units(1))}},
+		{"UCS2 surrogate pair ending a part", SegmentUCS2, join(units(65), smiley, units(1)),
+			[][]byte{join(units(65), smiley), units(1)}},
+		{"UCS2 255 parts", SegmentUCS2, units(67 * 255), slices.Repeat([][]byte{units(67)}, 255)},
+		{"UCS2 over 255 parts", SegmentUCS2, units(67*255 + 1), nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := tt.segment(tt.data, 0x2A)
+			if tt.want == nil {
+				if parts != nil {
+					t.Fatalf("got %d parts, want nil", len(parts))
+				}
+				return
+			}
+			if len(parts) != len(tt.want) {
+				t.Fatalf("got %d parts, want %d", len(parts), len(tt.want))
+			}
+			for i, part := range parts {
+				udh := []byte{0x05, 0x00, 0x03, 0x2A, byte(len(tt.want)), byte(i + 1)}
+				if !bytes.HasPrefix(part, udh) {
+					t.Fatalf("part %d UDH = % X, want % X", i+1, part[:min(len(part), udhConcatLength)], udh)
+				}
+				if got := part[udhConcatLength:]; !bytes.Equal(got, tt.want[i]) {
+					t.Fatalf("part %d = % X, want % X", i+1, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMaxShortMessageOctetsLeavesRoomForContent(t *testing.T) {
+	for _, n := range []int{1, 6, 7, 255} {
+		c := NewClient("localhost", 2775, "user", "pass", WithMaxShortMessageOctets(n))
+		if got := c.MaxShortMessageOctets(); got != defaultMaxShortMessageOctets {
+			t.Errorf("WithMaxShortMessageOctets(%d) set %d, want it ignored", n, got)
+		}
+	}
+
+	c := NewClient("localhost", 2775, "user", "pass", WithMaxShortMessageOctets(minShortMessageOctets))
+	for _, msg := range []*SMSMessage{
+		{Message: []byte("binary content"), IsBinary: true},
+		{Message: encodeUCS2("Привет"), IsUnicode: true},
+		{Message: []byte("plain text")},
+	} {
+		parts := c.segmentMessage(msg, 1)
+		var content []byte
+		for _, part := range parts {
+			if len(part) > minShortMessageOctets {
+				t.Fatalf("part of %d octets exceeds %d", len(part), minShortMessageOctets)
+			}
+			content = append(content, part[udhConcatLength:]...)
+		}
+		if !bytes.Equal(content, msg.Message) {
+			t.Errorf("parts carry %q, want %q", content, msg.Message)
+		}
+	}
+}
+
+func TestSendLongSMSSmallShortMessage(t *testing.T) {
+	var parts int
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID == SUBMIT_SM {
+			parts++
+		}
+		return false
+	})
+	c := smsc.client(WithMaxShortMessageOctets(minShortMessageOctets), WithClock(noSleepClock{}))
+	connect(t, c)
+
+	msg := &SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("0123456789"), IsBinary: true}
+	if _, err := c.SendLongSMS(msg); err != nil {
+		t.Fatal(err)
+	}
+	if parts != 5 {
+		t.Fatalf("sent %d parts, want 5", parts)
+	}
+}
+
+func TestSendLongSMSPartsFormat(t *testing.T) {
+	parts := make(chan *pdu, 2)
+	smsc := newTestSMSC(t, capturePDUs(SUBMIT_SM, parts))
+	c := smsc.client(WithMaxShortMessageOctets(16), WithClock(noSleepClock{}))
+	connect(t, c)
+
+	// Ten octets of content fit beside the UDH, so two parts
+	msg := &SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("0123456789abcdefghij"), IsBinary: true}
+	if _, err := c.SendLongSMS(msg); err != nil {
+		t.Fatal(err)
+	}
+	checkFormat(t, <-parts, `command_length:          70
+command_id:              0x00000004 (submit_sm)
+command_status:          0x00000000
+sequence_number:         2
+service_type:            ""
+source_addr_ton:         0x00
+source_addr_npi:         0x00
+source_addr:             "Shop"
+dest_addr_ton:           0x01
+dest_addr_npi:           0x01
+destination_addr:        "998901234567"
+esm_class:               0x40
+protocol_id:             0x00
+priority_flag:           0x00
+schedule_delivery_time:  ""
+validity_period:         ""
+registered_delivery:     0x00
+replace_if_present_flag: 0x00
+data_coding:             0x04
+sm_default_msg_id:       0x00
+sm_length:               0x10
+short_message:           05 00 03 01 02 01 30 31 32 33 34 35 36 37 38 39
+tlv 0x0426:              (more_messages_to_send) 01
+`)
+	checkFormat(t, <-parts, `command_length:          65
+command_id:              0x00000004 (submit_sm)
+command_status:          0x00000000
+sequence_number:         3
+service_type:            ""
+source_addr_ton:         0x00
+source_addr_npi:         0x00
+source_addr:             "Shop"
+dest_addr_ton:           0x01
+dest_addr_npi:           0x01
+destination_addr:        "998901234567"
+esm_class:               0x40
+protocol_id:             0x00
+priority_flag:           0x00
+schedule_delivery_time:  ""
+validity_period:         ""
+registered_delivery:     0x00
+replace_if_present_flag: 0x00
+data_coding:             0x04
+sm_default_msg_id:       0x00
+sm_length:               0x10
+short_message:           05 00 03 01 02 02 61 62 63 64 65 66 67 68 69 6A
+`)
+}
+
+func TestSendSegmentsChecksAllBeforeSending(t *testing.T) {
+	var submits int
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID == SUBMIT_SM {
+			submits++
+		}
+		return false
+	})
+	c := smsc.client(WithMaxShortMessageOctets(140), WithClock(noSleepClock{}))
+	connect(t, c)
+
+	base := SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", IsBinary: true}
+	for name, segments := range map[string][][]byte{
+		"oversized": {[]byte("part one"), bytes.Repeat([]byte{'x'}, 135)},
+		"empty":     {[]byte("part one"), {}},
+	} {
+		if _, err := c.SendSegments(base, segments); err == nil {
+			t.Errorf("%s segment accepted", name)
+		}
+	}
+	if submits != 0 {
+		t.Fatalf("%d parts sent before the bad segment was found", submits)
+	}
+
+	ids, err := c.SendSegments(base, [][]byte{[]byte("part one"), bytes.Repeat([]byte{'x'}, 134)})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("SendSegments = %v, %v", ids, err)
+	}
+}
+
+func TestLongMessageAdmittedOnce(t *testing.T) {
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID == SUBMIT_SM {
+			time.Sleep(40 * time.Millisecond)
+		}
+		return false
+	})
+	c := smsc.client(WithDestinationLimit(1, time.Minute), WithClock(noSleepClock{}))
+	connect(t, c)
+
+	long := bytes.Repeat([]byte("a"), 400) // three parts
+	msg := &SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: long,
+		SendDeadline: time.Now().Add(60 * time.Millisecond)}
+	if _, err := c.SendLongSMS(msg); err != nil {
+		t.Fatalf("long message cut short: %v", err)
+	}
+
+	// The message used up the destination's allowance
+	msg.SendDeadline = time.Time{}
+	if _, err := c.SendLongSMS(msg); !errors.Is(err, ErrDestinationThrottled) {
+		t.Fatalf("second message: %v, want ErrDestinationThrottled", err)
+	}
+}
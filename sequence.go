@@ -0,0 +1,35 @@
+package smpp
+
+import "sync/atomic"
+
+// maxSequence is the largest sequence_number allowed by the spec
+const maxSequence = 0x7FFFFFFF
+
+// maxSequenceSkips bounds how many pending sequence numbers nextSequence
+// skips before giving up
+const maxSequenceSkips = 1000
+
+// SequenceGenerator supplies sequence numbers for outgoing PDUs. Next must
+// return values in the range 1 to 0x7FFFFFFF and be safe for concurrent use.
+type SequenceGenerator interface {
+	Next() uint32
+}
+
+// counterSequence is the default generator: an atomic counter starting at 1
+// that wraps from 0x7FFFFFFF back to 1
+type counterSequence struct {
+	last atomic.Uint32
+}
+
+func (s *counterSequence) Next() uint32 {
+	for {
+		last := s.last.Load()
+		next := last + 1
+		if next > maxSequence {
+			next = 1
+		}
+		if s.last.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package smppprom exports the Stats of an smpp.Client as Prometheus
+// metrics. It is a module of its own so that the smpp package does not
+// depend on the Prometheus client; applications not using Prometheus do
+// not import it and pay nothing.
+package smppprom
+
+import (
+	"fmt"
+
+	smpp "github.com/Ucell-first/smpp2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector reads a client's Stats on every scrape. The values are those
+// of the client itself, so no counting happens outside scrapes.
+type Collector struct {
+	client *smpp.Client
+
+	submits        *prometheus.Desc
+	submitErrors   *prometheus.Desc
+	windowInUse    *prometheus.Desc
+	windowSize     *prometheus.Desc
+	bytes          *prometheus.Desc
+	reconnects     *prometheus.Desc
+	refusedInbound *prometheus.Desc
+	duplicateIDs   *prometheus.Desc
+	orphanedResps  *prometheus.Desc
+}
+
+// NewCollector returns a collector for c. labels are added to every
+// metric, to tell several clients apart, for example by SMSC.
+func NewCollector(c *smpp.Client, labels prometheus.Labels) *Collector {
+	desc := func(name, help string, variable ...string) *prometheus.Desc {
+		return prometheus.NewDesc("smpp_"+name, help, variable, labels)
+	}
+	return &Collector{
+		client:         c,
+		submits:        desc("submits_total", "Submits answered by the SMSC, by result.", "result"),
+		submitErrors:   desc("submit_errors_total", "Submits refused by the SMSC, by command_status.", "status"),
+		windowInUse:    desc("window_in_use", "Pipelined submits awaiting a response."),
+		windowSize:     desc("window_size", "Pipelined submits allowed to await a response at once."),
+		bytes:          desc("bytes_total", "PDU octets on the wire, by direction.", "direction"),
+		reconnects:     desc("reconnects_total", "Successful binds after the first."),
+		refusedInbound: desc("refused_inbound_total", "deliver_sm refused because the inbound buffer was full."),
+		duplicateIDs:   desc("duplicate_message_ids_total", "Submit responses repeating a recent message ID."),
+		orphanedResps:  desc("orphaned_responses_total", "Responses matching no outstanding request."),
+	}
+}
+
+// Register registers a collector for c with reg, which is typically a
+// *prometheus.Registry owned by the caller
+func Register(reg prometheus.Registerer, c *smpp.Client, labels prometheus.Labels) error {
+	return reg.Register(NewCollector(c, labels))
+}
+
+// Describe implements prometheus.Collector
+func (col *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		col.submits, col.submitErrors, col.windowInUse, col.windowSize, col.bytes,
+		col.reconnects, col.refusedInbound, col.duplicateIDs, col.orphanedResps,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (col *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := col.client.Stats()
+	counter := func(d *prometheus.Desc, v uint64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, float64(v), labels...)
+	}
+	gauge := func(d *prometheus.Desc, v int) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, float64(v))
+	}
+
+	counter(col.submits, s.SubmitsAccepted, "accepted")
+	counter(col.submits, s.SubmitsRefused, "refused")
+	for status, n := range s.SubmitErrors {
+		counter(col.submitErrors, n, fmt.Sprintf("0x%08X", status))
+	}
+	gauge(col.windowInUse, s.WindowInUse)
+	gauge(col.windowSize, s.WindowSize)
+	counter(col.bytes, s.BytesSent, "sent")
+	counter(col.bytes, s.BytesReceived, "received")
+	counter(col.reconnects, s.Reconnects)
+	counter(col.refusedInbound, s.RefusedInbound)
+	counter(col.duplicateIDs, s.DuplicateMessageIDs)
+	counter(col.orphanedResps, s.OrphanedResponses)
+}
@@ -0,0 +1,39 @@
+package smppprom
+
+import (
+	"testing"
+
+	smpp "github.com/Ucell-first/smpp2"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegister(t *testing.T) {
+	c := smpp.NewClient("127.0.0.1", 2775, "user", "pass", smpp.WithWindow(5))
+	reg := prometheus.NewRegistry()
+	if err := Register(reg, c, prometheus.Labels{"smsc": "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]*float64)
+	for _, f := range families {
+		m := f.GetMetric()[0]
+		switch {
+		case m.Gauge != nil:
+			got[f.GetName()] = m.Gauge.Value
+		case m.Counter != nil:
+			got[f.GetName()] = m.Counter.Value
+		}
+	}
+	for _, name := range []string{"smpp_submits_total", "smpp_window_in_use", "smpp_bytes_total", "smpp_reconnects_total"} {
+		if got[name] == nil {
+			t.Fatalf("%s not exported", name)
+		}
+	}
+	if v := *got["smpp_window_size"]; v != 5 {
+		t.Fatalf("smpp_window_size = %v, want 5", v)
+	}
+}
@@ -0,0 +1,122 @@
+package smpp
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// smppTimeLength is the length of a non-empty SMPP time string
+// (YYMMDDhhmmsstnnp), excluding the NULL terminator
+const smppTimeLength = 16
+
+// Relative times have no calendar, so years and months count as fixed
+// spans. The same spans are used when parsing and formatting.
+const (
+	relativeYear  = 365 * 24 * time.Hour
+	relativeMonth = 30 * 24 * time.Hour
+	relativeDay   = 24 * time.Hour
+)
+
+// ParseSMPPTime parses an SMPP time string such as schedule_delivery_time,
+// validity_period or a receipt's done date.
+//
+// Absolute times ('+' or '-' suffix) are returned in a fixed zone with the
+// encoded UTC offset and relative is false. Relative times ('R' suffix) are
+// returned as that offset from the zero time.Time with relative true; use
+// t.Sub(time.Time{}) to get the duration. Years and months in a relative
+// time count as 365 and 30 days.
+func ParseSMPPTime(s string) (time.Time, bool, error) {
+	if len(s) != smppTimeLength {
+		return time.Time{}, false, fmt.Errorf("smpp time %q: want %d characters", s, smppTimeLength)
+	}
+	var f [8]int // YY MM DD hh mm ss t nn
+	for i := range f {
+		start, end := i*2, i*2+2
+		if i == 6 {
+			start, end = 12, 13
+		} else if i == 7 {
+			start, end = 13, 15
+		}
+		n, err := strconv.Atoi(s[start:end])
+		if err != nil || n < 0 {
+			return time.Time{}, false, fmt.Errorf("smpp time %q: bad digits at %d", s, start)
+		}
+		f[i] = n
+	}
+	year, month, day, hour, min, sec, tenths, quarters := f[0], f[1], f[2], f[3], f[4], f[5], f[6], f[7]
+
+	switch s[15] {
+	case 'R':
+		d := time.Duration(year)*relativeYear +
+			time.Duration(month)*relativeMonth +
+			time.Duration(day)*relativeDay +
+			time.Duration(hour)*time.Hour +
+			time.Duration(min)*time.Minute +
+			time.Duration(sec)*time.Second
+		return time.Time{}.Add(d), true, nil
+	case '+', '-':
+		if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || min > 59 || sec > 59 {
+			return time.Time{}, false, fmt.Errorf("smpp time %q: field out of range", s)
+		}
+		if quarters > 48 {
+			return time.Time{}, false, fmt.Errorf("smpp time %q: UTC offset out of range", s)
+		}
+		offset := quarters * 15 * 60
+		if s[15] == '-' {
+			offset = -offset
+		}
+		loc := time.FixedZone("", offset)
+		t := time.Date(2000+year, time.Month(month), day, hour, min, sec, tenths*int(100*time.Millisecond), loc)
+		if t.Day() != day {
+			return time.Time{}, false, fmt.Errorf("smpp time %q: no such date", s)
+		}
+		return t, false, nil
+	default:
+		return time.Time{}, false, fmt.Errorf("smpp time %q: unknown format %q", s, s[15])
+	}
+}
+
+// FormatSMPPTime formats t as an absolute SMPP time, keeping t's UTC offset
+// when it is a whole number of quarter hours within ±12h and converting to
+// UTC otherwise. The year field has two digits, so only 2000-2099 round-trip.
+func FormatSMPPTime(t time.Time) string {
+	_, offset := t.Zone()
+	if offset%(15*60) != 0 || offset > 48*15*60 || offset < -48*15*60 {
+		t = t.UTC()
+		offset = 0
+	}
+	sign := byte('+')
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	return fmt.Sprintf("%02d%02d%02d%02d%02d%02d%d%02d%c",
+		t.Year()%100, int(t.Month()), t.Day(), t.Hour(), t.Minute(), t.Second(),
+		t.Nanosecond()/int(100*time.Millisecond), offset/(15*60), sign)
+}
+
+// FormatSMPPDuration formats d as a relative SMPP time, rounded down to
+// the second. Negative durations format as zero and durations beyond 99
+// years are capped.
+func FormatSMPPDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	years := d / relativeYear
+	if years > 99 {
+		years, d = 99, relativeYear-time.Second
+	} else {
+		d -= years * relativeYear
+	}
+	months := d / relativeMonth
+	d -= months * relativeMonth
+	days := d / relativeDay
+	d -= days * relativeDay
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	mins := d / time.Minute
+	d -= mins * time.Minute
+	secs := d / time.Second
+	return fmt.Sprintf("%02d%02d%02d%02d%02d%02d000R", years, months, days, hours, mins, secs)
+}
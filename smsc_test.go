@@ -0,0 +1,294 @@
+package smpp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSMSC is an in-process SMSC for exercising the client over TCP. Each
+// PDU it reads goes to handle; PDUs handle reports as unhandled get
+// defaultReply.
+type testSMSC struct {
+	t      testing.TB
+	ln     net.Listener
+	handle func(conn *smscConn, p *pdu) bool
+
+	mu        sync.Mutex
+	conns     []*smscConn
+	connected chan *smscConn
+}
+
+// smscConn is the SMSC side of one client connection
+type smscConn struct {
+	net.Conn
+	mu sync.Mutex // serializes writes
+}
+
+func newTestSMSC(t testing.TB, handle func(conn *smscConn, p *pdu) bool) *testSMSC {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSMSC{t: t, ln: ln, handle: handle, connected: make(chan *smscConn, 16)}
+	go s.accept()
+	t.Cleanup(s.close)
+	return s
+}
+
+func (s *testSMSC) accept() {
+	for {
+		nc, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		conn := &smscConn{Conn: nc}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		s.connected <- conn
+		go s.serve(conn)
+	}
+}
+
+func (s *testSMSC) serve(conn *smscConn) {
+	defer conn.Close()
+	for {
+		p, err := readTestPDU(conn)
+		if err != nil {
+			return
+		}
+		if s.handle == nil || !s.handle(conn, p) {
+			defaultReply(conn, p)
+		}
+	}
+}
+
+func (s *testSMSC) close() {
+	s.ln.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// client returns a client for the SMSC, with a short read timeout so a
+// failing test does not hang
+func (s *testSMSC) client(opts ...Option) *Client {
+	port := s.ln.Addr().(*net.TCPAddr).Port
+	opts = append([]Option{WithReadTimeout(2 * time.Second)}, opts...)
+	return NewClient("127.0.0.1", port, "user", "pass", opts...)
+}
+
+// conn waits for the next client connection
+func (s *testSMSC) conn() *smscConn {
+	s.t.Helper()
+	select {
+	case conn := <-s.connected:
+		return conn
+	case <-time.After(2 * time.Second):
+		s.t.Fatal("no client connected")
+		return nil
+	}
+}
+
+// send writes a PDU to the client
+func (c *smscConn) send(commandID, status, seq uint32, body []byte) error {
+	p := newPDU(commandID, seq)
+	p.commandStatus = status
+	p.write(body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.Write(p.encode())
+	return err
+}
+
+// reply answers req with status and body
+func (c *smscConn) reply(req *pdu, status uint32, body []byte) error {
+	return c.send(req.commandID|responseBit, status, req.sequenceNumber, body)
+}
+
+// defaultReply accepts binds and submits, giving each submit the message
+// ID "id-<sequence>", and acknowledges other requests with an empty body
+func defaultReply(conn *smscConn, p *pdu) {
+	switch {
+	case p.isResponse():
+	case p.commandID == BIND_TRANSMITTER, p.commandID == BIND_RECEIVER, p.commandID == BIND_TRANSCEIVER:
+		conn.reply(p, ESME_ROK, []byte("smsc\x00"))
+	case p.commandID == SUBMIT_SM, p.commandID == DATA_SM:
+		conn.reply(p, ESME_ROK, fmt.Appendf(nil, "id-%d\x00", p.sequenceNumber))
+	case p.commandID == UNBIND, p.commandID == ENQUIRE_LINK, p.commandID == REPLACE_SM:
+		conn.reply(p, ESME_ROK, nil)
+	default:
+		conn.send(GENERIC_NACK, ESME_RINVCMDID, p.sequenceNumber, nil)
+	}
+}
+
+// readTestPDU reads one PDU from r
+func readTestPDU(r io.Reader) (*pdu, error) {
+	header := make([]byte, pduHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	raw := make([]byte, binary.BigEndian.Uint32(header))
+	copy(raw, header)
+	if _, err := io.ReadFull(r, raw[pduHeaderLength:]); err != nil {
+		return nil, err
+	}
+	return decodePDU(raw)
+}
+
+// connect connects c, disconnecting it when the test ends
+func connect(t *testing.T, c *Client) {
+	t.Helper()
+	if err := c.Connect(false); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Disconnect() })
+}
+
+// deliverSMBody encodes a deliver_sm body from source to dest carrying
+// text, followed by the given TLVs
+func deliverSMBody(source, dest string, esmClass byte, text string, tlvs ...[]byte) []byte {
+	p := newPDU(DELIVER_SM, 0)
+	p.writeString("") // service_type
+	p.writeByte(1)
+	p.writeByte(1)
+	p.writeString(source)
+	p.writeByte(1)
+	p.writeByte(1)
+	p.writeString(dest)
+	p.writeByte(esmClass)
+	p.writeByte(0)    // protocol_id
+	p.writeByte(0)    // priority_flag
+	p.writeString("") // schedule_delivery_time
+	p.writeString("") // validity_period
+	p.writeByte(0)    // registered_delivery
+	p.writeByte(0)    // replace_if_present_flag
+	p.writeByte(0x03) // data_coding: Latin-1
+	p.writeByte(0)    // sm_default_msg_id
+	p.writeByte(byte(len(text)))
+	p.write([]byte(text))
+	for _, tlv := range tlvs {
+		p.write(tlv)
+	}
+	return p.body
+}
+
+// tlv encodes one optional parameter
+func tlv(tag uint16, value []byte) []byte {
+	p := newPDU(0, 0)
+	p.writeTLV(tag, value)
+	return p.body
+}
+
+// waitFor polls cond until it holds, failing the test after two seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// noSleepClock is the real clock except that Sleep returns at once, so
+// paced sends such as message parts go out at full speed
+type noSleepClock struct{ realClock }
+
+func (noSleepClock) Sleep(time.Duration) {}
+
+// fakeClock is a Clock that only moves when advanced. Every After call is
+// also reported on afters, so a test can tell what the client waits for.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+	afters chan time.Duration
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 10, 16, 12, 0, 0, 0, time.UTC), afters: make(chan time.Duration, 64)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+	} else {
+		f.timers = append(f.timers, fakeTimer{f.now.Add(d), ch})
+	}
+	f.mu.Unlock()
+	select {
+	case f.afters <- d:
+	default:
+	}
+	return ch
+}
+
+func (f *fakeClock) Sleep(d time.Duration) { <-f.After(d) }
+
+// advance moves the clock on by d, firing the timers due by then
+func (f *fakeClock) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	pending := f.timers[:0]
+	for _, timer := range f.timers {
+		if timer.at.After(f.now) {
+			pending = append(pending, timer)
+		} else {
+			timer.ch <- f.now
+		}
+	}
+	f.timers = pending
+}
+
+// awaitAfter waits for the client to call After(d), skipping other calls
+func (f *fakeClock) awaitAfter(t *testing.T, d time.Duration) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case got := <-f.afters:
+			if got == d {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("client never waited %s", d)
+		}
+	}
+}
+
+// recordSubmits returns an SMSC handler that decodes each submit_sm into
+// submits, which shares the deliver_sm layout, before the default reply
+func recordSubmits(submits chan *DeliverSM) func(*smscConn, *pdu) bool {
+	return func(conn *smscConn, p *pdu) bool {
+		if p.commandID == SUBMIT_SM {
+			if sm, err := parseDeliverSM(p.sequenceNumber, p.body); err == nil {
+				submits <- sm
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,42 @@
+package smpp
+
+// SourceAddrClassifier picks the source_addr_ton and source_addr_npi for a
+// sender address
+type SourceAddrClassifier func(addr string) (ton, npi byte)
+
+// ClassifySourceAddr is the default SourceAddrClassifier. Numeric senders
+// (digits, optionally after a '+') are taken as international numbers
+// (1/1); anything else is an alphanumeric sender ID (5/0).
+func ClassifySourceAddr(addr string) (ton, npi byte) {
+	if isNumericAddr(addr) {
+		return 1, 1
+	}
+	return 5, 0
+}
+
+// isNumericAddr reports whether addr is a non-empty run of digits with an
+// optional leading '+'
+func isNumericAddr(addr string) bool {
+	if len(addr) > 0 && addr[0] == '+' {
+		addr = addr[1:]
+	}
+	if addr == "" {
+		return false
+	}
+	for i := 0; i < len(addr); i++ {
+		if addr[i] < '0' || addr[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// sourceAddressing returns the TON and NPI to send for msg's source_addr.
+// Explicit values on the message win; the classifier only fills in the
+// unknown/unknown default.
+func (c *Client) sourceAddressing(msg *SMSMessage) (ton, npi byte) {
+	if c.classifySource == nil || msg.SourceAddrTON != 0 || msg.SourceAddrNPI != 0 || msg.SourceAddr == "" {
+		return msg.SourceAddrTON, msg.SourceAddrNPI
+	}
+	return c.classifySource(msg.SourceAddr)
+}
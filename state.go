@@ -0,0 +1,137 @@
+package smpp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrNotBound is returned by operations that need a bound session
+var ErrNotBound = errors.New("not bound to SMPP server")
+
+// State is a stage of the client's connection lifecycle
+type State int
+
+const (
+	StateDisconnected State = iota // no connection
+	StateDialing                   // opening the TCP/TLS connection
+	StateOpen                      // connected but not bound
+	StateBinding                   // bind in progress
+	StateBound                     // bound and able to submit
+	StateUnbinding                 // unbind in progress
+	StateReconnecting              // waiting to retry after a lost connection
+)
+
+func (s State) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateDialing:
+		return "dialing"
+	case StateOpen:
+		return "open"
+	case StateBinding:
+		return "binding"
+	case StateBound:
+		return "bound"
+	case StateUnbinding:
+		return "unbinding"
+	case StateReconnecting:
+		return "reconnecting"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// transitions lists the states each state may move to. Any state may drop
+// to StateDisconnected.
+var transitions = map[State][]State{
+	StateDisconnected: {StateDialing, StateReconnecting},
+	StateReconnecting: {StateDialing},
+	StateDialing:      {StateOpen, StateReconnecting},
+	StateOpen:         {StateBinding, StateReconnecting},
+	StateBinding:      {StateBound, StateOpen, StateReconnecting},
+	StateBound:        {StateUnbinding, StateReconnecting},
+	StateUnbinding:    {StateOpen},
+}
+
+// stateChangeBuffer is the capacity of the StateChanges channel
+const stateChangeBuffer = 16
+
+// StateChange is a lifecycle transition reported on StateChanges
+type StateChange struct {
+	From, To State
+}
+
+// stateMachine holds the lifecycle state. Transitions are checked against
+// the transitions table and published to the changes channel.
+type stateMachine struct {
+	mu      sync.Mutex
+	state   State
+	changes chan StateChange
+	bound   chan struct{} // closed on the next transition to StateBound
+}
+
+func newStateMachine() *stateMachine {
+	return &stateMachine{changes: make(chan StateChange, stateChangeBuffer)}
+}
+
+// get returns the current state
+func (m *stateMachine) get() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// transition moves to state to, failing if that is not allowed from the
+// current state. Moving to the current state is a no-op.
+func (m *stateMachine) transition(to State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from := m.state
+	if from == to {
+		return nil
+	}
+	if to != StateDisconnected && !slices.Contains(transitions[from], to) {
+		return fmt.Errorf("smpp: cannot go from %s to %s", from, to)
+	}
+	m.state = to
+
+	if to == StateBound && m.bound != nil {
+		close(m.bound)
+		m.bound = nil
+	}
+	select {
+	case m.changes <- StateChange{From: from, To: to}:
+	default: // nobody is draining the channel; drop rather than block
+	}
+	return nil
+}
+
+// boundSignal returns nil when bound, or else a channel closed when the
+// client next becomes bound
+func (m *stateMachine) boundSignal() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state == StateBound {
+		return nil
+	}
+	if m.bound == nil {
+		m.bound = make(chan struct{})
+	}
+	return m.bound
+}
+
+// State returns the client's current lifecycle state
+func (c *Client) State() State {
+	return c.state.get()
+}
+
+// StateChanges returns a channel reporting every lifecycle transition. It
+// buffers 16 unread changes and drops further ones while full, so a
+// consumer that only checks occasionally should use State instead.
+func (c *Client) StateChanges() <-chan StateChange {
+	return c.state.changes
+}
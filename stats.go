@@ -0,0 +1,99 @@
+package smpp
+
+import (
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// Stats is a point-in-time copy of a client's event counters
+type Stats struct {
+	// RefusedInbound counts deliver_sm PDUs answered with ESME_RMSGQFUL
+	// because the inbound buffer was full; the SMSC retries them later.
+	RefusedInbound uint64
+
+	// DuplicateMessageIDs counts submit responses repeating a message ID
+	// recently returned for another submit; only tracked with
+	// WithDuplicateDetection.
+	DuplicateMessageIDs uint64
+
+	// OrphanedResponses counts responses whose sequence number matched no
+	// outstanding request; they are dropped.
+	OrphanedResponses uint64
+
+	// Submits counts submit_sm and data_sm answered by the SMSC, of which
+	// SubmitsAccepted were accepted and SubmitsRefused were not.
+	// SubmitErrors counts the refused ones by command_status. Submits that
+	// got no answer are not counted. Submits is read last, so it is never
+	// below the sum of the other two.
+	Submits         uint64
+	SubmitsAccepted uint64
+	SubmitsRefused  uint64
+	SubmitErrors    map[uint32]uint64
+
+	// WindowInUse is how many pipelined submits await a response now, out
+	// of WindowSize (see WithWindow)
+	WindowInUse int
+	WindowSize  int
+
+	// BytesSent and BytesReceived count PDU octets on the wire, headers
+	// included, over all connections
+	BytesSent     uint64
+	BytesReceived uint64
+
+	// Reconnects counts successful binds after the first
+	Reconnects uint64
+}
+
+// counters are the live values behind Stats
+type counters struct {
+	refusedInbound      atomic.Uint64
+	duplicateMessageIDs atomic.Uint64
+	orphanedResponses   atomic.Uint64
+	submits             atomic.Uint64
+	submitsAccepted     atomic.Uint64
+	submitsRefused      atomic.Uint64
+	binds               atomic.Uint64
+
+	mu           sync.Mutex
+	submitErrors map[uint32]uint64
+}
+
+// submitted counts a submit response with status
+func (c *counters) submitted(status uint32, success bool) {
+	c.submits.Add(1)
+	if success {
+		c.submitsAccepted.Add(1)
+		return
+	}
+	c.submitsRefused.Add(1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.submitErrors == nil {
+		c.submitErrors = make(map[uint32]uint64)
+	}
+	c.submitErrors[status]++
+}
+
+// Stats returns the client's current counters
+func (c *Client) Stats() Stats {
+	s := Stats{
+		RefusedInbound:      c.counters.refusedInbound.Load(),
+		DuplicateMessageIDs: c.counters.duplicateMessageIDs.Load(),
+		OrphanedResponses:   c.counters.orphanedResponses.Load(),
+		SubmitsAccepted:     c.counters.submitsAccepted.Load(),
+		SubmitsRefused:      c.counters.submitsRefused.Load(),
+		WindowInUse:         len(c.window),
+		WindowSize:          cap(c.window),
+		BytesSent:           c.conn.bytesSent.Load(),
+		BytesReceived:       c.conn.bytesReceived.Load(),
+	}
+	s.Submits = c.counters.submits.Load()
+	if binds := c.counters.binds.Load(); binds > 1 {
+		s.Reconnects = binds - 1
+	}
+	c.counters.mu.Lock()
+	s.SubmitErrors = maps.Clone(c.counters.submitErrors)
+	c.counters.mu.Unlock()
+	return s
+}
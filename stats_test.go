@@ -0,0 +1,43 @@
+package smpp
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestStatsCountsSubmitsBytesAndReconnects(t *testing.T) {
+	var rejected atomic.Bool
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID == SUBMIT_SM && rejected.CompareAndSwap(false, true) {
+			conn.reply(p, ESME_RTHROTTLED, nil)
+			return true
+		}
+		return false
+	})
+	c := smsc.client(WithWindow(4))
+	connect(t, c)
+
+	msg := &SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")}
+	c.SendSMS(msg)
+	if _, err := c.SendSMS(msg); err != nil {
+		t.Fatal(err)
+	}
+	c.Disconnect()
+	if err := c.Connect(false); err != nil {
+		t.Fatal(err)
+	}
+
+	s := c.Stats()
+	if s.Submits != 2 || s.SubmitsAccepted != 1 || s.SubmitsRefused != 1 || s.SubmitErrors[ESME_RTHROTTLED] != 1 {
+		t.Fatalf("submits = %d, accepted %d, refused %d, errors %v", s.Submits, s.SubmitsAccepted, s.SubmitsRefused, s.SubmitErrors)
+	}
+	if s.BytesSent == 0 || s.BytesReceived == 0 {
+		t.Fatalf("bytes sent %d, received %d", s.BytesSent, s.BytesReceived)
+	}
+	if s.Reconnects != 1 {
+		t.Fatalf("reconnects = %d, want 1", s.Reconnects)
+	}
+	if s.WindowSize != 4 || s.WindowInUse != 0 {
+		t.Fatalf("window %d of %d in use", s.WindowInUse, s.WindowSize)
+	}
+}
@@ -0,0 +1,140 @@
+package smpp
+
+import "fmt"
+
+// Command status codes (SMPP 3.4 section 5.1.3)
+const (
+	ESME_ROK              uint32 = 0x00000000
+	ESME_RINVMSGLEN       uint32 = 0x00000001
+	ESME_RINVCMDLEN       uint32 = 0x00000002
+	ESME_RINVCMDID        uint32 = 0x00000003
+	ESME_RINVBNDSTS       uint32 = 0x00000004
+	ESME_RALYBND          uint32 = 0x00000005
+	ESME_RINVPRTFLG       uint32 = 0x00000006
+	ESME_RINVREGDLVFLG    uint32 = 0x00000007
+	ESME_RSYSERR          uint32 = 0x00000008
+	ESME_RINVSRCADR       uint32 = 0x0000000A
+	ESME_RINVDSTADR       uint32 = 0x0000000B
+	ESME_RINVMSGID        uint32 = 0x0000000C
+	ESME_RBINDFAIL        uint32 = 0x0000000D
+	ESME_RINVPASWD        uint32 = 0x0000000E
+	ESME_RINVSYSID        uint32 = 0x0000000F
+	ESME_RCANCELFAIL      uint32 = 0x00000011
+	ESME_RREPLACEFAIL     uint32 = 0x00000013
+	ESME_RMSGQFUL         uint32 = 0x00000014
+	ESME_RINVSERTYP       uint32 = 0x00000015
+	ESME_RINVNUMDESTS     uint32 = 0x00000033
+	ESME_RINVDLNAME       uint32 = 0x00000034
+	ESME_RINVDESTFLAG     uint32 = 0x00000040
+	ESME_RINVSUBREP       uint32 = 0x00000042
+	ESME_RINVESMCLASS     uint32 = 0x00000043
+	ESME_RCNTSUBDL        uint32 = 0x00000044
+	ESME_RSUBMITFAIL      uint32 = 0x00000045
+	ESME_RINVSRCTON       uint32 = 0x00000048
+	ESME_RINVSRCNPI       uint32 = 0x00000049
+	ESME_RINVDSTTON       uint32 = 0x00000050
+	ESME_RINVDSTNPI       uint32 = 0x00000051
+	ESME_RINVSYSTYP       uint32 = 0x00000053
+	ESME_RINVREPFLAG      uint32 = 0x00000054
+	ESME_RINVNUMMSGS      uint32 = 0x00000055
+	ESME_RTHROTTLED       uint32 = 0x00000058
+	ESME_RINVSCHED        uint32 = 0x00000061
+	ESME_RINVEXPIRY       uint32 = 0x00000062
+	ESME_RINVDFTMSGID     uint32 = 0x00000063
+	ESME_RX_T_APPN        uint32 = 0x00000064
+	ESME_RX_P_APPN        uint32 = 0x00000065
+	ESME_RX_R_APPN        uint32 = 0x00000066
+	ESME_RQUERYFAIL       uint32 = 0x00000067
+	ESME_RINVOPTPARSTREAM uint32 = 0x000000C0
+	ESME_ROPTPARNOTALLWD  uint32 = 0x000000C1
+	ESME_RINVPARLEN       uint32 = 0x000000C2
+	ESME_RMISSINGOPTPARAM uint32 = 0x000000C3
+	ESME_RINVOPTPARAMVAL  uint32 = 0x000000C4
+	ESME_RDELIVERYFAILURE uint32 = 0x000000FE
+	ESME_RUNKNOWNERR      uint32 = 0x000000FF
+)
+
+// statuses maps each command status to its name and description
+var statuses = map[uint32][2]string{
+	ESME_ROK:              {"ESME_ROK", "no error"},
+	ESME_RINVMSGLEN:       {"ESME_RINVMSGLEN", "message length is invalid"},
+	ESME_RINVCMDLEN:       {"ESME_RINVCMDLEN", "command length is invalid"},
+	ESME_RINVCMDID:        {"ESME_RINVCMDID", "invalid command id"},
+	ESME_RINVBNDSTS:       {"ESME_RINVBNDSTS", "incorrect bind status for given command"},
+	ESME_RALYBND:          {"ESME_RALYBND", "already in bound state"},
+	ESME_RINVPRTFLG:       {"ESME_RINVPRTFLG", "invalid priority flag"},
+	ESME_RINVREGDLVFLG:    {"ESME_RINVREGDLVFLG", "invalid registered delivery flag"},
+	ESME_RSYSERR:          {"ESME_RSYSERR", "system error"},
+	ESME_RINVSRCADR:       {"ESME_RINVSRCADR", "invalid source address"},
+	ESME_RINVDSTADR:       {"ESME_RINVDSTADR", "invalid destination address"},
+	ESME_RINVMSGID:        {"ESME_RINVMSGID", "message id is invalid"},
+	ESME_RBINDFAIL:        {"ESME_RBINDFAIL", "bind failed"},
+	ESME_RINVPASWD:        {"ESME_RINVPASWD", "invalid password"},
+	ESME_RINVSYSID:        {"ESME_RINVSYSID", "invalid system id"},
+	ESME_RCANCELFAIL:      {"ESME_RCANCELFAIL", "cancel_sm failed"},
+	ESME_RREPLACEFAIL:     {"ESME_RREPLACEFAIL", "replace_sm failed"},
+	ESME_RMSGQFUL:         {"ESME_RMSGQFUL", "message queue full"},
+	ESME_RINVSERTYP:       {"ESME_RINVSERTYP", "invalid service type"},
+	ESME_RINVNUMDESTS:     {"ESME_RINVNUMDESTS", "invalid number of destinations"},
+	ESME_RINVDLNAME:       {"ESME_RINVDLNAME", "invalid distribution list name"},
+	ESME_RINVDESTFLAG:     {"ESME_RINVDESTFLAG", "invalid destination flag"},
+	ESME_RINVSUBREP:       {"ESME_RINVSUBREP", "invalid submit with replace request"},
+	ESME_RINVESMCLASS:     {"ESME_RINVESMCLASS", "invalid esm_class field data"},
+	ESME_RCNTSUBDL:        {"ESME_RCNTSUBDL", "cannot submit to distribution list"},
+	ESME_RSUBMITFAIL:      {"ESME_RSUBMITFAIL", "submit_sm or submit_multi failed"},
+	ESME_RINVSRCTON:       {"ESME_RINVSRCTON", "invalid source address TON"},
+	ESME_RINVSRCNPI:       {"ESME_RINVSRCNPI", "invalid source address NPI"},
+	ESME_RINVDSTTON:       {"ESME_RINVDSTTON", "invalid destination address TON"},
+	ESME_RINVDSTNPI:       {"ESME_RINVDSTNPI", "invalid destination address NPI"},
+	ESME_RINVSYSTYP:       {"ESME_RINVSYSTYP", "invalid system_type field"},
+	ESME_RINVREPFLAG:      {"ESME_RINVREPFLAG", "invalid replace_if_present flag"},
+	ESME_RINVNUMMSGS:      {"ESME_RINVNUMMSGS", "invalid number of messages"},
+	ESME_RTHROTTLED:       {"ESME_RTHROTTLED", "throttling error, ESME has exceeded allowed message limits"},
+	ESME_RINVSCHED:        {"ESME_RINVSCHED", "invalid scheduled delivery time"},
+	ESME_RINVEXPIRY:       {"ESME_RINVEXPIRY", "invalid message validity period"},
+	ESME_RINVDFTMSGID:     {"ESME_RINVDFTMSGID", "predefined message invalid or not found"},
+	ESME_RX_T_APPN:        {"ESME_RX_T_APPN", "ESME receiver temporary app error code"},
+	ESME_RX_P_APPN:        {"ESME_RX_P_APPN", "ESME receiver permanent app error code"},
+	ESME_RX_R_APPN:        {"ESME_RX_R_APPN", "ESME receiver reject message error code"},
+	ESME_RQUERYFAIL:       {"ESME_RQUERYFAIL", "query_sm request failed"},
+	ESME_RINVOPTPARSTREAM: {"ESME_RINVOPTPARSTREAM", "error in the optional part of the PDU body"},
+	ESME_ROPTPARNOTALLWD:  {"ESME_ROPTPARNOTALLWD", "optional parameter not allowed"},
+	ESME_RINVPARLEN:       {"ESME_RINVPARLEN", "invalid parameter length"},
+	ESME_RMISSINGOPTPARAM: {"ESME_RMISSINGOPTPARAM", "expected optional parameter missing"},
+	ESME_RINVOPTPARAMVAL:  {"ESME_RINVOPTPARAMVAL", "invalid optional parameter value"},
+	ESME_RDELIVERYFAILURE: {"ESME_RDELIVERYFAILURE", "delivery failure"},
+	ESME_RUNKNOWNERR:      {"ESME_RUNKNOWNERR", "unknown error"},
+}
+
+// StatusText describes a command status as "NAME: description", e.g.
+// "ESME_RINVPASWD: invalid password". Codes in the reserved and
+// SMSC-specific ranges are described by their number.
+func StatusText(status uint32) string {
+	if s, ok := statuses[status]; ok {
+		return s[0] + ": " + s[1]
+	}
+	if status >= 0x400 && status <= 0x4FF {
+		return fmt.Sprintf("0x%08X: SMSC vendor-specific error", status)
+	}
+	return fmt.Sprintf("0x%08X: unknown status", status)
+}
+
+// StatusError is a non-zero command_status returned by the SMSC
+type StatusError struct {
+	Status uint32
+}
+
+func (e *StatusError) Error() string {
+	return StatusText(e.Status)
+}
+
+// Retryable reports whether the status is transient, so the same request
+// may succeed if sent again later: throttling, a full queue, a system
+// error, a temporary receiver error or a lost bind.
+func (e *StatusError) Retryable() bool {
+	switch e.Status {
+	case ESME_RTHROTTLED, ESME_RMSGQFUL, ESME_RSYSERR, ESME_RX_T_APPN, ESME_RINVBNDSTS:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package smpp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxStreamedCString bounds a C-Octet String read from a stream, above
+// the longest mandatory field of any PDU split at message_payload
+const maxStreamedCString = 256
+
+// PDUStream decodes PDUs one at a time from r, for inbound traffic that
+// carries large message_payload values. The fields of each PDU are read
+// into a buffer reused from one PDU to the next, and the message_payload
+// of a deliver_sm, submit_sm or data_sm is not read at all but handed out
+// as an io.Reader, so its size costs no allocation.
+type PDUStream struct {
+	r       *bufio.Reader
+	body    io.LimitedReader // the unread rest of the current PDU body
+	payload io.LimitedReader // the unread rest of its message_payload
+	fields  []byte
+	header  [pduHeaderLength]byte
+}
+
+// StreamedPDU is a PDU read from a PDUStream. Fields and Payload are only
+// valid until the stream's next call to Next.
+type StreamedPDU struct {
+	CommandID      uint32
+	CommandStatus  uint32
+	SequenceNumber uint32
+
+	// Fields is the body up to message_payload: the mandatory fields and
+	// the TLVs before it, or the whole body when there is no payload. The
+	// stream's Trailer returns the TLVs after the payload.
+	Fields []byte
+
+	// Payload reads the message_payload value, PayloadLength bytes. It is
+	// empty when the PDU has none.
+	Payload       io.Reader
+	PayloadLength int
+}
+
+// NewPDUStream returns a stream decoding PDUs from r
+func NewPDUStream(r io.Reader) *PDUStream {
+	return &PDUStream{r: bufio.NewReader(r)}
+}
+
+// Next reads the next PDU up to its message_payload, first skipping
+// whatever is left unread of the current one
+func (s *PDUStream) Next() (*StreamedPDU, error) {
+	if _, err := io.Copy(io.Discard, &s.body); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(s.r, s.header[:]); err != nil {
+		return nil, err
+	}
+	p := &StreamedPDU{
+		CommandID:      binary.BigEndian.Uint32(s.header[4:8]),
+		CommandStatus:  binary.BigEndian.Uint32(s.header[8:12]),
+		SequenceNumber: binary.BigEndian.Uint32(s.header[12:16]),
+	}
+	length := binary.BigEndian.Uint32(s.header[0:4])
+	if length < pduHeaderLength || length > maxPDULength {
+		return nil, fmt.Errorf("%w: %d", errBadCommandLength, length)
+	}
+	s.body = io.LimitedReader{R: s.r, N: int64(length - pduHeaderLength)}
+	s.payload = io.LimitedReader{R: &s.body}
+	s.fields = s.fields[:0]
+
+	if err := s.readFields(p.CommandID); err != nil {
+		return nil, fmt.Errorf("%s: %w", commandName(p.CommandID), err)
+	}
+	p.Fields = s.fields
+	p.Payload = &s.payload
+	p.PayloadLength = int(s.payload.N)
+	return p, nil
+}
+
+// readFields reads the body into s.fields up to message_payload, leaving
+// s.payload limited to its value
+func (s *PDUStream) readFields(commandID uint32) error {
+	switch commandID {
+	case DELIVER_SM, SUBMIT_SM:
+		// service_type, source and destination, esm_class to sm_length
+		if err := s.readAddressing(); err != nil {
+			return err
+		}
+		if err := s.take(3); err != nil { // esm_class, protocol_id, priority_flag
+			return err
+		}
+		if err := s.takeCString(); err != nil { // schedule_delivery_time
+			return err
+		}
+		if err := s.takeCString(); err != nil { // validity_period
+			return err
+		}
+		if err := s.take(5); err != nil { // registered_delivery to sm_length
+			return err
+		}
+		if err := s.take(int(s.fields[len(s.fields)-1])); err != nil { // short_message
+			return err
+		}
+	case DATA_SM:
+		if err := s.readAddressing(); err != nil {
+			return err
+		}
+		if err := s.take(3); err != nil { // esm_class, registered_delivery, data_coding
+			return err
+		}
+	default:
+		return s.take(int(s.body.N))
+	}
+
+	for s.body.N > 0 {
+		start := len(s.fields)
+		if err := s.take(4); err != nil {
+			return err
+		}
+		tag := binary.BigEndian.Uint16(s.fields[start:])
+		n := int64(binary.BigEndian.Uint16(s.fields[start+2:]))
+		if n > s.body.N {
+			return fmt.Errorf("TLV 0x%04X: body truncated", tag)
+		}
+		if tag == TLV_MESSAGE_PAYLOAD {
+			s.fields = s.fields[:start]
+			s.payload.N = n
+			return nil
+		}
+		if err := s.take(int(n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readAddressing reads service_type and the source and destination
+// addresses, which open deliver_sm, submit_sm and data_sm alike
+func (s *PDUStream) readAddressing() error {
+	if err := s.takeCString(); err != nil {
+		return err
+	}
+	for range 2 {
+		if err := s.take(2); err != nil { // ton, npi
+			return err
+		}
+		if err := s.takeCString(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// take appends the next n body bytes to s.fields
+func (s *PDUStream) take(n int) error {
+	if int64(n) > s.body.N {
+		return errors.New("body truncated")
+	}
+	start := len(s.fields)
+	s.fields = append(s.fields, make([]byte, n)...)
+	_, err := io.ReadFull(&s.body, s.fields[start:])
+	return err
+}
+
+// takeCString appends the next C-Octet String, with its NULL, to s.fields
+func (s *PDUStream) takeCString() error {
+	for range maxStreamedCString {
+		if err := s.take(1); err != nil {
+			return err
+		}
+		if s.fields[len(s.fields)-1] == 0 {
+			return nil
+		}
+	}
+	return errors.New("missing NULL terminator")
+}
+
+// Trailer skips whatever is left unread of the current PDU's payload and
+// returns the TLVs after it. It is valid until the next call to Next.
+func (s *PDUStream) Trailer() ([]byte, error) {
+	if _, err := io.Copy(io.Discard, &s.payload); err != nil {
+		return nil, err
+	}
+	start := len(s.fields)
+	if err := s.take(int(s.body.N)); err != nil {
+		return nil, err
+	}
+	return s.fields[start:], nil
+}
+
+// DeliverSM decodes Fields of a deliver_sm. When the content came in
+// message_payload its Message is empty; read the content from Payload.
+func (p *StreamedPDU) DeliverSM() (*DeliverSM, error) {
+	if p.CommandID != DELIVER_SM {
+		return nil, fmt.Errorf("%s is not a deliver_sm", commandName(p.CommandID))
+	}
+	return parseDeliverSM(p.SequenceNumber, p.Fields)
+}
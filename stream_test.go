@@ -0,0 +1,100 @@
+package smpp
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestPDUStreamPayload(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 4000)
+	first := newPDU(DELIVER_SM, 1)
+	first.write(deliverSMBody("998901234567", "1234", 0, "",
+		tlv(TLV_SOURCE_PORT, []byte{0x0B, 0x84}),
+		tlv(TLV_MESSAGE_PAYLOAD, payload),
+		tlv(TLV_MORE_MESSAGES, []byte{1})))
+	second := newPDU(ENQUIRE_LINK, 2)
+
+	var wire bytes.Buffer
+	wire.Write(first.encode())
+	wire.Write(second.encode())
+	s := NewPDUStream(&wire)
+
+	p, err := s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PayloadLength != len(payload) {
+		t.Fatalf("payload length = %d, want %d", p.PayloadLength, len(payload))
+	}
+	d, err := p.DeliverSM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Source.Addr != "998901234567" || d.SourcePort == nil || *d.SourcePort != 2948 {
+		t.Fatalf("deliver_sm = %+v", d)
+	}
+	got, err := io.ReadAll(p.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatal("payload differs")
+	}
+	trailer, err := s.Trailer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := tlv(TLV_MORE_MESSAGES, []byte{1}); !bytes.Equal(trailer, want) {
+		t.Fatalf("trailer = % x, want % x", trailer, want)
+	}
+
+	p, err = s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.CommandID != ENQUIRE_LINK || p.SequenceNumber != 2 || p.PayloadLength != 0 {
+		t.Fatalf("second PDU = %+v", p)
+	}
+	if _, err := s.Next(); err != io.EOF {
+		t.Fatalf("err = %v at the end, want io.EOF", err)
+	}
+}
+
+func TestPDUStreamSkipsUnreadPayload(t *testing.T) {
+	var wire bytes.Buffer
+	for seq := uint32(1); seq <= 2; seq++ {
+		p := newPDU(DATA_SM, seq)
+		p.writeString("")
+		p.write([]byte{1, 1})
+		p.writeString("Shop")
+		p.write([]byte{1, 1})
+		p.writeString("998901234567")
+		p.write([]byte{0, 0, 0})
+		p.writeTLV(TLV_MESSAGE_PAYLOAD, bytes.Repeat([]byte{byte(seq)}, 30000))
+		wire.Write(p.encode())
+	}
+	s := NewPDUStream(&wire)
+
+	p, err := s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.CopyN(io.Discard, p.Payload, 100)
+
+	p, err = s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 1)
+	if _, err := p.Payload.Read(b); err != nil || p.SequenceNumber != 2 || b[0] != 2 {
+		t.Fatalf("second PDU seq %d starts % x (%v)", p.SequenceNumber, b, err)
+	}
+}
+
+func TestPDUStreamRefusesBadLength(t *testing.T) {
+	s := NewPDUStream(bytes.NewReader([]byte{0, 0, 0, 4, 0, 0, 0, 0x15, 0, 0, 0, 0, 0, 0, 0, 1}))
+	if _, err := s.Next(); err == nil {
+		t.Fatal("accepted a command_length below the header size")
+	}
+}
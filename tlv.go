@@ -0,0 +1,67 @@
+package smpp
+
+import "fmt"
+
+// Optional parameter (TLV) tags
+const (
+	TLV_RECEIPTED_MESSAGE_ID   uint16 = 0x001E
+	TLV_NUMBER_OF_MESSAGES     uint16 = 0x0304
+	TLV_SOURCE_PORT            uint16 = 0x020A
+	TLV_DESTINATION_PORT       uint16 = 0x020B
+	TLV_MS_AVAILABILITY_STATUS uint16 = 0x0422
+	TLV_NETWORK_ERROR_CODE     uint16 = 0x0423
+	TLV_MESSAGE_PAYLOAD        uint16 = 0x0424
+	TLV_MESSAGE_STATE          uint16 = 0x0427
+	TLV_MORE_MESSAGES          uint16 = 0x0426
+	TLV_ITS_REPLY_TYPE         uint16 = 0x1380
+	TLV_ITS_SESSION_INFO       uint16 = 0x1383
+)
+
+// maxTLVLength is the largest value a TLV length field can describe
+const maxTLVLength = 0xFFFF
+
+// ITSReplyType tells the handset what kind of reply an interactive
+// teleservice (ITS) message expects
+type ITSReplyType byte
+
+const (
+	ITSReplyDigit         ITSReplyType = 0
+	ITSReplyNumber        ITSReplyType = 1
+	ITSReplyTelephoneNo   ITSReplyType = 2
+	ITSReplyPassword      ITSReplyType = 3
+	ITSReplyCharacterLine ITSReplyType = 4
+	ITSReplyMenu          ITSReplyType = 5
+	ITSReplyDate          ITSReplyType = 6
+	ITSReplyTime          ITSReplyType = 7
+	ITSReplyContinue      ITSReplyType = 8
+)
+
+// ITSSessionInfo identifies a message within an ITS session. On the wire it
+// is two octets: the session number, then the sequence number in bits 7-1
+// with bit 0 set on the last message of the session.
+type ITSSessionInfo struct {
+	SessionNumber  byte
+	SequenceNumber byte // 0-127
+	EndOfSession   bool
+}
+
+// encode packs s into the two-octet its_session_info value
+func (s ITSSessionInfo) encode() []byte {
+	b := s.SequenceNumber << 1
+	if s.EndOfSession {
+		b |= 0x01
+	}
+	return []byte{s.SessionNumber, b}
+}
+
+// parseITSSessionInfo unpacks a two-octet its_session_info value
+func parseITSSessionInfo(v []byte) (ITSSessionInfo, error) {
+	if len(v) != 2 {
+		return ITSSessionInfo{}, fmt.Errorf("its_session_info has length %d", len(v))
+	}
+	return ITSSessionInfo{
+		SessionNumber:  v[0],
+		SequenceNumber: v[1] >> 1,
+		EndOfSession:   v[1]&0x01 != 0,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package smpp
+
+import "fmt"
+
+// Range of command IDs reserved for SMSC vendor-specific commands
+const (
+	vendorCommandFirst uint32 = 0x00010000
+	vendorCommandLast  uint32 = 0x000100FF
+)
+
+// DecodedPDU is an inbound PDU handed to a registered command handler
+type DecodedPDU struct {
+	CommandID      uint32
+	CommandStatus  uint32
+	SequenceNumber uint32
+	Body           []byte
+}
+
+// Response is a command handler's reply. It is sent with the request's
+// command ID (plus the response bit) and sequence number.
+type Response struct {
+	CommandStatus uint32
+	Body          []byte
+}
+
+// RegisterCommand routes inbound PDUs carrying a vendor-specific command
+// ID (0x00010000-0x000100FF) to handler and sends back the response it
+// returns; a nil response sends nothing. A nil handler unregisters id.
+// Unregistered commands are still answered with generic_nack. Handlers run on the read path and must not
+// call back into the Client.
+func (c *Client) RegisterCommand(id uint32, handler func(*DecodedPDU) *Response) error {
+	if id < vendorCommandFirst || id > vendorCommandLast {
+		return fmt.Errorf("command ID 0x%08X is outside the vendor range 0x%08X-0x%08X", id, vendorCommandFirst, vendorCommandLast)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if handler == nil {
+		delete(c.commands, id)
+		return nil
+	}
+	if c.commands == nil {
+		c.commands = make(map[uint32]func(*DecodedPDU) *Response)
+	}
+	c.commands[id] = handler
+	return nil
+}
+
+// handleVendorCommand runs the handler registered for req, reporting false
+// if there is none. Must be called with c.mu held.
+func (c *Client) handleVendorCommand(req *pdu) (bool, error) {
+	handler, ok := c.commands[req.commandID]
+	if !ok {
+		return false, nil
+	}
+
+	resp := handler(&DecodedPDU{
+		CommandID:      req.commandID,
+		CommandStatus:  req.commandStatus,
+		SequenceNumber: req.sequenceNumber,
+		Body:           req.body,
+	})
+	if resp == nil {
+		return true, nil
+	}
+
+	return true, c.sendResponse(req.commandID, req.sequenceNumber, resp.CommandStatus, resp.Body)
+}
@@ -0,0 +1,53 @@
+package smpp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegisterCommandNilUnregisters(t *testing.T) {
+	const vendorCommand = vendorCommandFirst + 1
+	resps := make(chan *pdu, 4)
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		if p.commandID == vendorCommand|responseBit || p.commandID == GENERIC_NACK {
+			resps <- p
+			return true
+		}
+		return false
+	})
+	c := smsc.client(WithBindMode(BindTransceiver))
+	if err := c.RegisterCommand(vendorCommand, func(*DecodedPDU) *Response {
+		return &Response{CommandStatus: ESME_ROK}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	connect(t, c)
+	conn := smsc.conn()
+
+	await := func() *pdu {
+		t.Helper()
+		select {
+		case p := <-resps:
+			return p
+		case <-time.After(2 * time.Second):
+			t.Fatal("vendor command not answered")
+			return nil
+		}
+	}
+
+	conn.send(vendorCommand, ESME_ROK, 1, nil)
+	if p := await(); p.commandID != vendorCommand|responseBit {
+		t.Fatalf("answered with %s, want the handler's response", commandName(p.commandID))
+	}
+
+	if err := c.RegisterCommand(vendorCommand, nil); err != nil {
+		t.Fatal(err)
+	}
+	conn.send(vendorCommand, ESME_ROK, 2, nil)
+	if p := await(); p.commandID != GENERIC_NACK {
+		t.Fatalf("answered with %s after unregistering, want generic_nack", commandName(p.commandID))
+	}
+	if c.State() != StateBound {
+		t.Fatalf("state = %s, want bound", c.State())
+	}
+}
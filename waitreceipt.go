@@ -0,0 +1,134 @@
+package smpp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// earlyReceipts is how many receipts are kept for SubmitAndWaitReceipt
+// calls that have not yet seen their submit_sm_resp
+const earlyReceipts = 1024
+
+// receiptWaiters hands delivery receipts to SubmitAndWaitReceipt calls,
+// keyed by message ID
+type receiptWaiters struct {
+	mu      sync.Mutex
+	calls   int // SubmitAndWaitReceipt calls in progress
+	waiting map[string]chan *DeliveryReceipt
+
+	// early holds receipts that arrived while calls were in progress but
+	// before any of them waited for that ID, as a fast SMSC's receipt can
+	// beat the submit_sm_resp to the caller
+	early *lru[string, *DeliveryReceipt]
+}
+
+// begin marks a call in progress, so receipts are kept until it waits
+func (w *receiptWaiters) begin() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.waiting == nil {
+		w.waiting = make(map[string]chan *DeliveryReceipt)
+	}
+	if w.calls == 0 {
+		w.early = newLRU[string, *DeliveryReceipt](earlyReceipts)
+	}
+	w.calls++
+}
+
+// wait returns the channel that receives the final receipt for messageID
+func (w *receiptWaiters) wait(messageID string) chan *DeliveryReceipt {
+	ch := make(chan *DeliveryReceipt, 1)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if r, ok := w.early.get(messageID); ok {
+		ch <- r
+		return ch
+	}
+	w.waiting[messageID] = ch
+	return ch
+}
+
+// end forgets messageID, if the call got that far, and drops the early
+// receipts once no call is in progress
+func (w *receiptWaiters) end(messageID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.waiting, messageID)
+	if w.calls--; w.calls == 0 {
+		w.early = nil
+	}
+}
+
+// claim hands a final receipt to the call waiting for it. With calls in
+// progress but none waiting for it, it is kept for them. It reports whether
+// r was taken either way.
+func (w *receiptWaiters) claim(r *DeliveryReceipt) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ch, ok := w.waiting[r.MessageID]; ok {
+		delete(w.waiting, r.MessageID)
+		ch <- r
+		return true
+	}
+	if w.calls == 0 {
+		return false
+	}
+	w.early.put(r.MessageID, r)
+	return true
+}
+
+// claimReceipt hands d to SubmitAndWaitReceipt if it is a final receipt
+// arriving while a call is in progress
+func (c *Client) claimReceipt(d *DeliverSM) bool {
+	if !d.IsDeliveryReceipt() {
+		return false
+	}
+	r, err := d.DeliveryReceipt()
+	if err != nil || r.Intermediate {
+		return false
+	}
+	return c.receipts.claim(r)
+}
+
+// SubmitAndWaitReceipt sends msg like SendSMS, requesting a delivery
+// receipt if msg does not, and waits for the final receipt until ctx is
+// done. It needs a transceiver bind, on which the receipt comes back, and
+// a ctx deadline allowing for the network to deliver the message, which
+// may take minutes; when ctx ends first the error wraps ctx.Err(). The
+// receipt is still passed to the OnDeliverSM handler if one is set. Without
+// one, every final receipt arriving during the call is acknowledged, as it
+// may be for this message while its submit_sm_resp is still on the way.
+func (c *Client) SubmitAndWaitReceipt(ctx context.Context, msg *SMSMessage) (*DeliveryReceipt, error) {
+	if c.bindMode != BindTransceiver {
+		return nil, errors.New("SubmitAndWaitReceipt needs a transceiver bind")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.startDeliverWorkers()
+	c.mu.Unlock()
+
+	m := *msg
+	if m.registeredDelivery()&registeredDeliveryReceiptMask == byte(ReceiptNone) {
+		m.RegisteredDelivery.Receipt = ReceiptSuccessOrFailure
+	}
+
+	c.receipts.begin()
+	var messageID string
+	defer func() { c.receipts.end(messageID) }()
+
+	messageID, err := c.SendSMS(&m)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case r := <-c.receipts.wait(messageID):
+		return r, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no delivery receipt for message %s: %w", messageID, ctx.Err())
+	}
+}
@@ -0,0 +1,75 @@
+package smpp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// receiptFor encodes a deliver_sm carrying the final receipt for messageID
+func receiptFor(messageID string) []byte {
+	text := fmt.Sprintf("id:%s sub:001 dlvrd:001 submit date:2410161200 done date:2410161201 stat:DELIVRD err:000 text:", messageID)
+	return deliverSMBody("998901234567", "Shop", byte(MessageTypeDeliveryReceipt), text)
+}
+
+func TestSubmitAndWaitReceipt(t *testing.T) {
+	resps := make(chan *pdu, 4)
+	smsc := newTestSMSC(t, func(conn *smscConn, p *pdu) bool {
+		switch p.commandID {
+		case SUBMIT_SM:
+			if sm, err := parseDeliverSM(p.sequenceNumber, p.body); err != nil || sm.RegisteredDelivery.Receipt == ReceiptNone {
+				conn.reply(p, ESME_RINVREGDLVFLG, nil)
+				return true
+			}
+			// The receipt races ahead of the submit_sm_resp
+			id := fmt.Sprintf("id-%d", p.sequenceNumber)
+			conn.send(DELIVER_SM, ESME_ROK, 100+p.sequenceNumber, receiptFor("other"))
+			conn.send(DELIVER_SM, ESME_ROK, 200+p.sequenceNumber, receiptFor(id))
+			defaultReply(conn, p)
+			return true
+		case DELIVER_SM_RESP:
+			resps <- p
+			return true
+		}
+		return false
+	})
+	c := smsc.client(WithBindMode(BindTransceiver))
+	connect(t, c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	r, err := c.SubmitAndWaitReceipt(ctx, &SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.MessageID == "other" || r.Stat != "DELIVRD" {
+		t.Fatalf("receipt = %+v", r)
+	}
+	for range 2 {
+		if resp := awaitResp(t, resps); resp.commandStatus != ESME_ROK {
+			t.Fatalf("receipt answered with %#x", resp.commandStatus)
+		}
+	}
+}
+
+func TestSubmitAndWaitReceiptTimesOut(t *testing.T) {
+	smsc := newTestSMSC(t, nil)
+	c := smsc.client(WithBindMode(BindTransceiver))
+	connect(t, c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.SubmitAndWaitReceipt(ctx, &SMSMessage{SourceAddr: "Shop", DestAddr: "998901234567", Message: []byte("hi")})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the context deadline", err)
+	}
+}
+
+func TestSubmitAndWaitReceiptNeedsTransceiver(t *testing.T) {
+	c := NewClient("127.0.0.1", 2775, "user", "pass")
+	if _, err := c.SubmitAndWaitReceipt(context.Background(), &SMSMessage{DestAddr: "998901234567", Message: []byte("hi")}); err == nil {
+		t.Fatal("waited for a receipt on a transmitter bind")
+	}
+}